@@ -29,10 +29,17 @@ func (c *CredentialsClient) Search(ctx context.Context, pathexp string, teamIDs
 	return c.listWorker(ctx, v, p)
 }
 
-// Get returns all credentials at the given path.
-func (c *CredentialsClient) Get(ctx context.Context, path string, p ProgressFunc) ([]apitypes.CredentialEnvelope, error) {
+// Get returns all credentials at the given path. If resolveReferences is set,
+// references within their values are resolved; otherwise the values are
+// returned as they were set.
+func (c *CredentialsClient) Get(ctx context.Context, path string, resolveReferences bool,
+	p ProgressFunc) ([]apitypes.CredentialEnvelope, error) {
+
 	v := &url.Values{}
 	v.Set("path", path)
+	if resolveReferences {
+		v.Set("resolve-references", "true")
+	}
 
 	return c.listWorker(ctx, v, p)
 }
@@ -47,6 +54,59 @@ func (c *CredentialsClient) listWorker(ctx context.Context, v *url.Values, p Pro
 	return createEnvelopesFromResp(resp)
 }
 
+// History returns every version of the credential with the given name at the
+// given pathexp, most recent first.
+func (c *CredentialsClient) History(ctx context.Context, pathexp, name string, skipDecryption bool,
+	p ProgressFunc) ([]apitypes.CredentialHistoryItem, error) {
+
+	v := &url.Values{}
+	v.Set("pathexp", pathexp)
+	v.Set("name", name)
+	if skipDecryption {
+		v.Set("skip-decryption", "true")
+	}
+
+	var resp []apitypes.CredentialHistoryResp
+	err := c.client.DaemonRoundTrip(ctx, "GET", "/credentials/history", v, nil, &resp, p)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]apitypes.CredentialHistoryItem, len(resp))
+	for i, r := range resp {
+		envs, err := createEnvelopesFromResp([]apitypes.CredentialResp{r.Credential})
+		if err != nil {
+			return nil, err
+		}
+
+		items[i] = apitypes.CredentialHistoryItem{
+			Credential:        envs[0],
+			CredentialVersion: r.CredentialVersion,
+			KeyringVersion:    r.KeyringVersion,
+			KeyringCreated:    r.KeyringCreated,
+			SetBy:             r.SetBy,
+		}
+	}
+
+	return items, nil
+}
+
+// Current returns the value of the most recent version of the credential with
+// the given name at the given pathexp. Only that version is decrypted.
+func (c *CredentialsClient) Current(ctx context.Context, pathexp, name string) (*apitypes.CredentialValue, error) {
+	v := &url.Values{}
+	v.Set("pathexp", pathexp)
+	v.Set("name", name)
+
+	value := &apitypes.CredentialValue{}
+	err := c.client.DaemonRoundTrip(ctx, "GET", "/credentials/current", v, nil, value, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
 // Create creates the given credential
 func (c *CredentialsClient) Create(ctx context.Context, creds []*apitypes.CredentialEnvelope,
 	progress ProgressFunc) ([]apitypes.CredentialEnvelope, error) {
@@ -82,14 +142,23 @@ func createEnvelopesFromResp(resp []apitypes.CredentialResp) ([]apitypes.Credent
 			}
 
 			cBody = &cBodyV2
+		case 3:
+			cBodyV3 := apitypes.CredentialV3{}
+			err := json.Unmarshal(c.Body, &cBodyV3)
+			if err != nil {
+				return nil, err
+			}
+
+			cBody = &cBodyV3
 		default:
 			return nil, errors.New("Unknown credential version")
 		}
 
 		results = append(results, apitypes.CredentialEnvelope{
-			ID:      c.ID,
-			Version: c.Version,
-			Body:    &cBody,
+			ID:             c.ID,
+			Version:        c.Version,
+			Body:           &cBody,
+			ReferenceError: c.ReferenceError,
 		})
 	}
 
@@ -89,6 +89,8 @@ func (w *rawWorklogItem) setDetails() error {
 		fallthrough
 	case apitypes.MachineKeyringMembersWorklogType:
 		w.WorklogItem.Details = &apitypes.KeyringMembersWorklogDetails{}
+	case apitypes.SecretStaleWorklogType:
+		w.WorklogItem.Details = &apitypes.SecretStaleWorklogDetails{}
 	default:
 		return errUnknownWorklogType
 	}
@@ -1,13 +1,16 @@
 package apitypes
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
 )
 
 var errMistmatchedType = errors.New("Mismatched type and value in credential")
@@ -18,6 +21,7 @@ const (
 	intCV
 	floatCV
 	undecryptedCV // only used internally to the daemon
+	binaryCV
 )
 
 // CredentialEnvelope is an unencrypted credential object with a
@@ -26,6 +30,10 @@ type CredentialEnvelope struct {
 	ID      *identity.ID `json:"id"`
 	Version uint8        `json:"version"`
 	Body    *Credential  `json:"body"`
+
+	// ReferenceError is set if the references within the credential's value
+	// could not be resolved, in which case the value is left as it is.
+	ReferenceError string `json:"reference_error,omitempty"`
 }
 
 // CredentialResp is used to facilitate unmarshalling of versioned objects
@@ -33,15 +41,38 @@ type CredentialResp struct {
 	ID      *identity.ID    `json:"id"`
 	Version uint8           `json:"version"`
 	Body    json.RawMessage `json:"body"`
+
+	ReferenceError string `json:"reference_error,omitempty"`
 }
 
-// Credential interface is either a v1 or v2 credential object
+// CredentialHistoryItem is a single version of a credential, along with the
+// version of the keyring it was stored in, and who set it.
+type CredentialHistoryItem struct {
+	Credential        CredentialEnvelope
+	CredentialVersion int
+	KeyringVersion    int
+	KeyringCreated    time.Time
+	SetBy             *identity.ID
+}
+
+// CredentialHistoryResp is used to facilitate unmarshalling of the versioned
+// credential within a CredentialHistoryItem.
+type CredentialHistoryResp struct {
+	Credential        CredentialResp `json:"credential"`
+	CredentialVersion int            `json:"credential_version"`
+	KeyringVersion    int            `json:"keyring_version"`
+	KeyringCreated    time.Time      `json:"keyring_created_at"`
+	SetBy             *identity.ID   `json:"set_by"`
+}
+
+// Credential interface is either a v1, v2 or v3 credential object
 type Credential interface {
 	GetName() string
 	GetOrgID() *identity.ID
 	GetPathExp() *pathexp.PathExp
 	GetProjectID() *identity.ID
 	GetValue() *CredentialValue
+	GetMetadata() *primitive.CredentialMetadata
 }
 
 // BaseCredential is the body of an unencrypted Credential
@@ -82,6 +113,12 @@ func (c *BaseCredential) GetValue() *CredentialValue {
 	return c.Value
 }
 
+// GetMetadata returns the metadata describing the credential, which only v3
+// credentials have.
+func (c *BaseCredential) GetMetadata() *primitive.CredentialMetadata {
+	return nil
+}
+
 // CredentialV2 is the body of an unencrypted Credential
 type CredentialV2 struct {
 	BaseCredential
@@ -102,6 +139,32 @@ func (c *CredentialV2) GetValue() *CredentialValue {
 	return c.Value
 }
 
+// CredentialV3 is the body of an unencrypted Credential, along with its
+// metadata
+type CredentialV3 struct {
+	CredentialV2
+	Metadata *primitive.CredentialMetadata `json:"metadata,omitempty"`
+
+	// ClearMetadata lists the metadata fields to clear when setting the
+	// credential. Fields which are neither given nor cleared are kept from
+	// the previous version.
+	ClearMetadata []string `json:"clear_metadata,omitempty"`
+}
+
+// GetMetadata returns the metadata describing the credential, or nil if it
+// has none.
+func (c *CredentialV3) GetMetadata() *primitive.CredentialMetadata {
+	return c.Metadata
+}
+
+// The metadata fields which can be cleared when setting a credential.
+const (
+	MetadataDescription = "description"
+	MetadataExpires     = "expires"
+	MetadataOwner       = "owner"
+	MetadataTags        = "tags"
+)
+
 // CredentialValue is the raw value of a credential.
 type CredentialValue struct {
 	cvtype int
@@ -119,8 +182,14 @@ func (c *CredentialValue) IsUndecrypted() bool {
 	return c.cvtype == undecryptedCV
 }
 
+// IsBinary returns if this credential holds binary data, which is stored
+// base64 encoded.
+func (c *CredentialValue) IsBinary() bool {
+	return c.cvtype == binaryCV
+}
+
 // String returns the string representation of this credential. It panics
-// if the credential was deleted.
+// if the credential was deleted. Binary credentials are returned decoded.
 func (c *CredentialValue) String() string {
 	if c.cvtype == unsetCV {
 		panic("CredentialValue has been unset")
@@ -161,6 +230,8 @@ func (c *CredentialValue) MarshalJSON() ([]byte, error) {
 		impl.Body.Type = "number"
 	case floatCV:
 		impl.Body.Type = "number"
+	case binaryCV:
+		impl.Body.Type = "binary"
 	case unsetCV:
 		impl.Body.Type = "undefined"
 	case undecryptedCV:
@@ -238,6 +309,21 @@ func (c *CredentialValue) UnmarshalJSON(b []byte) error {
 		}
 
 		c.value = v.String()
+	case "binary":
+		c.cvtype = binaryCV
+		var v string
+		err := json.Unmarshal(impl.Body.Value, &v)
+		if err != nil {
+			return errMistmatchedType
+		}
+
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return errMistmatchedType
+		}
+
+		c.raw = v
+		c.value = string(b)
 	default:
 		return errors.New("Decoding type " + impl.Body.Type + " is not supported")
 	}
@@ -276,6 +362,16 @@ func NewFloatCredentialValue(f float64) *CredentialValue {
 	}
 }
 
+// NewBinaryCredentialValue creates a CredentialValue with a binary value. Its
+// raw value is the base64 encoding of the data.
+func NewBinaryCredentialValue(b []byte) *CredentialValue {
+	return &CredentialValue{
+		cvtype: binaryCV,
+		value:  string(b),
+		raw:    base64.StdEncoding.EncodeToString(b),
+	}
+}
+
 // NewUndecryptedCredentialValue creates a CredentialValue with an undecrypted
 // value
 func NewUndecryptedCredentialValue() *CredentialValue {
@@ -84,6 +84,17 @@ func TestCredentialValueUnmarshalJSON(t *testing.T) {
 
 	})
 
+	tc("binary", "AP9hAA==", func(t *testing.T, c *CredentialValue) {
+		if !c.IsBinary() {
+			t.Error("value is not binary")
+		}
+
+		expected := "\x00\xffa\x00"
+		if c.String() != expected {
+			t.Errorf("wrong value! had: %q wanted: %q", c.String(), expected)
+		}
+	})
+
 	tc("undecrypted", "", func(t *testing.T, c *CredentialValue) {
 		if c.IsUnset() {
 			t.Error("value is unset")
@@ -111,3 +122,37 @@ func TestCredentialValueUnmarshalJSON(t *testing.T) {
 		}
 	})
 }
+
+func TestBinaryCredentialValueRoundTrip(t *testing.T) {
+	data := []byte("\x00\xff\x10binary\n")
+
+	b, err := json.Marshal(NewBinaryCredentialValue(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c := CredentialValue{}
+	if err := json.Unmarshal(b, &c); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !c.IsBinary() {
+		t.Error("value is not binary")
+	}
+	if c.String() != string(data) {
+		t.Errorf("wrong value! had: %q wanted: %q", c.String(), data)
+	}
+
+	raw, err := c.Raw()
+	if err != nil || raw != "AP8QYmluYXJ5Cg==" {
+		t.Errorf("wrong raw value! had: %#v wanted: %q", raw, "AP8QYmluYXJ5Cg==")
+	}
+}
+
+func TestBinaryCredentialValueInvalidBase64(t *testing.T) {
+	jsonString := strconv.Quote(`{"version":1,"body":{"type":"binary","value":"not base64!"}}`)
+	c := CredentialValue{}
+	if err := json.Unmarshal([]byte(jsonString), &c); err == nil {
+		t.Error("expected an error decoding invalid base64")
+	}
+}
@@ -3,6 +3,7 @@ package apitypes
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/dchest/blake2b"
 
@@ -22,6 +23,7 @@ const (
 	InviteApproveWorklogType
 	UserKeyringMembersWorklogType
 	MachineKeyringMembersWorklogType
+	SecretStaleWorklogType
 
 	AnyWorklogType WorklogType = 0xff
 )
@@ -180,6 +182,28 @@ func (s *SecretRotateWorklogDetails) Summary() string {
 	return "A user's access was revoked. This secret's value should be changed."
 }
 
+// SecretStaleWorklogDetails holds WorklogItem details for the
+// SecretStaleWorklogType.
+type SecretStaleWorklogDetails struct {
+	PathExp *pathexp.PathExp `json:"pathexp"`
+	Name    string           `json:"name"`
+	SetAt   time.Time        `json:"set_at"`
+	MaxAge  time.Duration    `json:"max_age"`
+
+	// MaxAgePathExp is the path expression the max age is configured for.
+	MaxAgePathExp *pathexp.PathExp `json:"max_age_pathexp"`
+}
+
+// Subject returns the human readable subject of this WorklogItem.
+func (s *SecretStaleWorklogDetails) Subject() string {
+	return s.PathExp.String() + "/" + s.Name
+}
+
+// Summary returns the human readable summary of this WorklogItem.
+func (s *SecretStaleWorklogDetails) Summary() string {
+	return "This secret is older than its maximum age. Its value should be changed."
+}
+
 // Type returns this item's type
 func (w *WorklogItem) Type() WorklogType {
 	return w.ID.Type()
@@ -198,6 +222,8 @@ func (t WorklogType) String() string {
 		fallthrough
 	case MachineKeyringMembersWorklogType:
 		return "secret"
+	case SecretStaleWorklogType:
+		return "secret"
 	default:
 		return "n/a"
 	}
@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+var reportFormats = []string{"csv", "json", "markdown"}
+
+func init() {
+	access := cli.Command{
+		Name:     "access",
+		Usage:    "Review who has access to what within an organization",
+		Category: "ACCESS CONTROL",
+		Subcommands: []cli.Command{
+			{
+				Name:      "report",
+				Usage:     "Report the access every user and machine has to each path, through their teams' policies",
+				ArgsUsage: "[path to file] or use stdout redirection (e.g. `torus access report > access.csv`)",
+				Flags: []cli.Flag{
+					orgFlag("Use this organization.", false),
+					newPlaceholder("format, f", "FORMAT", "Format of the report ("+
+						strings.Join(reportFormats, ", ")+")", reportFormats[0], "", false),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, accessReportCmd,
+				),
+			},
+		},
+	}
+
+	Cmds = append(Cmds, access)
+}
+
+const allPolicyActions = primitive.PolicyAction(primitive.PolicyActionCreate | primitive.PolicyActionRead |
+	primitive.PolicyActionUpdate | primitive.PolicyActionDelete | primitive.PolicyActionList)
+
+const (
+	userIdentity    = "user"
+	machineIdentity = "machine"
+)
+
+// accessIdentity is a user or machine, and its memberships of the org's teams
+type accessIdentity struct {
+	Name        string
+	Type        string
+	Memberships []envelope.Membership
+}
+
+// accessRow is the decision for a single action of an identity on a path.
+type accessRow struct {
+	Identity string   `json:"identity"`
+	Type     string   `json:"type"`
+	Path     string   `json:"path"`
+	Action   string   `json:"action"`
+	Effect   string   `json:"effect"`
+	Broad    bool     `json:"broad"`
+	Teams    []string `json:"teams"`
+	Policies []string `json:"policies"`
+}
+
+// accessReport expands the policies attached to the teams of each identity
+// into one row per identity, path and action. Paths are those of the
+// statements which apply to the identity, and each action is decided by every
+// statement covering the path, so a broader deny overrides a narrower allow.
+//
+// Rows are ordered by identity, with users before machines, then by path and
+// crudl order.
+func accessReport(orgName string, identities []accessIdentity, teams []envelope.Team,
+	policies []envelope.Policy, attachments []envelope.PolicyAttachment) []accessRow {
+
+	sort.Slice(identities, func(i, j int) bool {
+		if identities[i].Type != identities[j].Type {
+			return identities[i].Type > identities[j].Type
+		}
+		return identities[i].Name < identities[j].Name
+	})
+
+	rows := []accessRow{}
+	for _, ident := range identities {
+		username := ident.Name
+		if ident.Type == machineIdentity {
+			username = "machine-" + ident.Name
+		}
+		vars := map[string]string{"org": orgName, "username": username}
+
+		statements := attachedStatements(ident.Memberships, teams, policies, attachments)
+
+		paths := []string{}
+		seen := make(map[string]bool)
+		for _, s := range statements {
+			path := expandResource(s.Statement.Resource, vars)
+			if !strings.HasPrefix(path, "/") || seen[path] {
+				continue
+			}
+
+			seen[path] = true
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			for _, action := range splitActions(allPolicyActions) {
+				d := evaluateAccess(statements, action, path, vars)
+				if len(d.Statements) == 0 {
+					continue
+				}
+
+				row := accessRow{
+					Identity: ident.Name,
+					Type:     ident.Type,
+					Path:     path,
+					Action:   action.String(),
+					Effect:   "allow",
+					Teams:    []string{},
+					Policies: []string{},
+				}
+				if !d.Permits(action) {
+					row.Effect = "deny"
+				}
+				row.Broad = row.Effect == "allow" && broadPath(path)
+
+				for _, s := range d.Statements {
+					if !containsString(row.Teams, s.Team) {
+						row.Teams = append(row.Teams, s.Team)
+					}
+					if !containsString(row.Policies, s.Policy) {
+						row.Policies = append(row.Policies, s.Policy)
+					}
+				}
+				sort.Strings(row.Teams)
+				sort.Strings(row.Policies)
+
+				rows = append(rows, row)
+			}
+		}
+	}
+
+	return rows
+}
+
+// broadPath returns whether a path covers every project of an org, like
+// /org/*/** does.
+func broadPath(path string) bool {
+	parts := strings.Split(path, "/")
+	return len(parts) > 2 && (parts[1] == "*" || parts[2] == "*")
+}
+
+// writeAccessReport writes the rows in the given format.
+func writeAccessReport(w io.Writer, format string, rows []accessRow) error {
+	switch format {
+	case "json":
+		return writeStructured(w, format, rows)
+	case "markdown":
+		fmt.Fprintln(w, "| Identity | Type | Path | Action | Effect | Broad | Teams | Policies |")
+		fmt.Fprintln(w, "| --- | --- | --- | --- | --- | --- | --- | --- |")
+
+		for _, r := range rows {
+			path := "`" + strings.Replace(r.Path, "|", "\\|", -1) + "`"
+			broad := ""
+			if r.Broad {
+				path = "**" + path + "**"
+				broad = "**yes**"
+			}
+
+			_, err := fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s | %s | %s |\n", r.Identity, r.Type,
+				path, r.Action, r.Effect, broad, strings.Join(r.Teams, ", "), strings.Join(r.Policies, ", "))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	default:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"identity", "type", "path", "action", "effect", "broad", "teams", "policies"})
+		for _, r := range rows {
+			cw.Write([]string{r.Identity, r.Type, r.Path, r.Action, r.Effect, strconv.FormatBool(r.Broad),
+				strings.Join(r.Teams, " "), strings.Join(r.Policies, " ")})
+		}
+
+		cw.Flush()
+		return cw.Error()
+	}
+}
+
+// accessIdentities returns every user and active machine in the org, along
+// with their memberships.
+func accessIdentities(c context.Context, client *api.Client, org *envelope.Org,
+	teams []envelope.Team) ([]accessIdentity, error) {
+
+	memberships, err := client.Memberships.List(c, org.ID, nil, nil)
+	if err != nil {
+		return nil, errs.NewErrorExitError("Could not retrieve list of memberships.", err)
+	}
+
+	machineTeams := make(map[identity.ID]bool)
+	for _, t := range teams {
+		if isMachineTeam(t.Body) {
+			machineTeams[*t.ID] = true
+		}
+	}
+
+	userMemberships := make(map[identity.ID][]envelope.Membership)
+	userIDs := []identity.ID{}
+	for _, m := range memberships {
+		if machineTeams[*m.Body.TeamID] {
+			continue
+		}
+
+		if _, ok := userMemberships[*m.Body.OwnerID]; !ok {
+			userIDs = append(userIDs, *m.Body.OwnerID)
+		}
+		userMemberships[*m.Body.OwnerID] = append(userMemberships[*m.Body.OwnerID], m)
+	}
+
+	identities := []accessIdentity{}
+	if len(userIDs) > 0 {
+		profiles, err := client.Profiles.ListByID(c, userIDs)
+		if err != nil {
+			return nil, errs.NewErrorExitError("Could not retrieve users.", err)
+		}
+
+		for _, p := range profiles {
+			identities = append(identities, accessIdentity{
+				Name:        p.Body.Username,
+				Type:        userIdentity,
+				Memberships: userMemberships[*p.ID],
+			})
+		}
+	}
+
+	state := primitive.MachineActiveState
+	machines, err := client.Machines.List(c, org.ID, &state, nil, nil)
+	if err != nil {
+		return nil, errs.NewErrorExitError("Could not retrieve machines.", err)
+	}
+
+	for _, m := range machines {
+		identities = append(identities, accessIdentity{
+			Name:        m.Machine.Body.Name,
+			Type:        machineIdentity,
+			Memberships: m.Memberships,
+		})
+	}
+
+	return identities, nil
+}
+
+func accessReportCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	filename := ""
+	if len(args) > 0 {
+		if len(args) != 1 {
+			return errs.NewUsageExitError("Only one argument can be supplied.", ctx)
+		}
+
+		filename = args[0]
+	}
+
+	format := ctx.String("format")
+	if !containsString(reportFormats, format) {
+		return errs.NewUsageExitError("Invalid format provided: "+format, ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, _, _, err := selectOrg(c, client, ctx.String("org"), false)
+	if err != nil {
+		return err
+	}
+
+	teams, err := client.Teams.GetByOrg(c, org.ID)
+	if err != nil {
+		return errs.NewErrorExitError("Could not retrieve teams", err)
+	}
+
+	identities, err := accessIdentities(c, client, org, teams)
+	if err != nil {
+		return err
+	}
+
+	policies, err := client.Policies.List(c, org.ID, "")
+	if err != nil {
+		return errs.NewErrorExitError(policyListFailed, err)
+	}
+
+	attachments, err := client.Policies.AttachmentsList(c, org.ID, nil, nil)
+	if err != nil {
+		return errs.NewErrorExitError(policyListFailed, err)
+	}
+
+	rows := accessReport(org.Body.Name, identities, teams, policies, attachments)
+
+	var w io.Writer = os.Stdout
+	if filename != "" {
+		fd, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return errs.NewErrorExitError("Could not write to given filepath", err)
+		}
+		defer fd.Close()
+
+		w = fd
+	}
+
+	return writeAccessReport(w, format, rows)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func (f *policyFixture) memberships(t *testing.T, teams ...string) []envelope.Membership {
+	owner := mutableID(t, &primitive.User{})
+
+	out := []envelope.Membership{}
+	for _, team := range teams {
+		for _, tm := range f.teams {
+			if tm.Body.Name == team {
+				out = append(out, envelope.Membership{
+					ID:   mutableID(t, &primitive.Membership{}),
+					Body: &primitive.Membership{OrgID: f.org.ID, OwnerID: owner, TeamID: tm.ID},
+				})
+			}
+		}
+	}
+
+	return out
+}
+
+func TestAccessReport(t *testing.T) {
+	f := newPolicyFixture(t)
+	f.policy(t, "user", "ci-everything", "", []primitive.PolicyStatement{
+		{Effect: primitive.PolicyEffectAllow, Action: primitive.PolicyActionRead | primitive.PolicyActionList,
+			Resource: "/${org}/*/*/*/*/*/*"},
+		{Effect: primitive.PolicyEffectDeny, Action: primitive.PolicyActionRead, Resource: "/${org}/p/prod/*/*/*/*"},
+	}, "ci")
+
+	identities := []accessIdentity{
+		{Name: "deploy", Type: machineIdentity, Memberships: f.memberships(t, "ci")},
+		{Name: "alice", Type: userIdentity, Memberships: f.memberships(t, "member", "ops")},
+	}
+
+	rows := accessReport("o", identities, f.teams, f.policies, f.attachments)
+
+	got := []string{}
+	for _, r := range rows {
+		got = append(got, strings.Join([]string{r.Identity, r.Path, r.Action, r.Effect,
+			strings.Join(r.Policies, ","), map[bool]string{true: "broad"}[r.Broad]}, " "))
+	}
+
+	want := []string{
+		"alice /o/p/dev/*/*/*/* read allow generated-allow-1 ",
+		"alice /o/p/prod/*/*/*/* read allow read-prod ",
+		"alice /o/p/prod/*/*/*/* list allow read-prod ",
+		"deploy /o/*/*/*/*/*/* read allow ci-everything broad",
+		"deploy /o/*/*/*/*/*/* list allow ci-everything broad",
+		"deploy /o/p/prod/*/*/*/* read deny ci-everything ",
+		"deploy /o/p/prod/*/*/*/* list allow ci-everything ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected rows:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestWriteAccessReport(t *testing.T) {
+	rows := []accessRow{
+		{Identity: "alice", Type: userIdentity, Path: "/o/*/[dev|prod]/*/*/*/*", Action: "read", Effect: "allow",
+			Broad: true, Teams: []string{"member", "ops"}, Policies: []string{"read-all"}},
+	}
+
+	tcs := []struct {
+		format string
+		want   string
+	}{
+		{"csv", "identity,type,path,action,effect,broad,teams,policies\n" +
+			"alice,user,/o/*/[dev|prod]/*/*/*/*,read,allow,true,member ops,read-all\n"},
+		{"markdown", "| Identity | Type | Path | Action | Effect | Broad | Teams | Policies |\n" +
+			"| --- | --- | --- | --- | --- | --- | --- | --- |\n" +
+			"| alice | user | **`/o/*/[dev\\|prod]/*/*/*/*`** | read | allow | **yes** | member, ops | read-all |\n"},
+	}
+
+	for _, tc := range tcs {
+		buf := &bytes.Buffer{}
+		if err := writeAccessReport(buf, tc.format, rows); err != nil {
+			t.Fatalf("writeAccessReport(%s) expected no error, got %s", tc.format, err)
+		}
+
+		if buf.String() != tc.want {
+			t.Errorf("writeAccessReport(%s) expected %q, got %q", tc.format, tc.want, buf.String())
+		}
+	}
+}
+
+func TestBroadPath(t *testing.T) {
+	tcs := map[string]bool{
+		"/o/*/*/*/*/*/*":     true,
+		"/o/*":               true,
+		"/*/p/*/*/*/*/*":     true,
+		"/o/p/*/*/*/*/*":     false,
+		"/o/[p|q]/*/*/*/*/*": false,
+		"/o":                 false,
+	}
+
+	for path, want := range tcs {
+		if got := broadPath(path); got != want {
+			t.Errorf("broadPath(%q) expected %t, got %t", path, want, got)
+		}
+	}
+}
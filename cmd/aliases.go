@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/dirprefs"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/prefs"
+)
+
+// aliasedCredential is a credential exposed under a different name.
+type aliasedCredential struct {
+	apitypes.Credential
+	name string
+}
+
+// GetName returns the alias
+func (c *aliasedCredential) GetName() string {
+	return c.name
+}
+
+// loadSecretAliases returns the secret name aliases set in the .torus.json
+// file, unless reading context has been disabled. An error is returned if
+// the aliases are invalid, as checked by checkSecretAliases.
+func loadSecretAliases() (map[string]string, error) {
+	p, err := prefs.NewPreferences()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.Core.Context {
+		return nil, nil
+	}
+
+	d, err := dirprefs.Load(true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSecretAliases(d.Aliases); err != nil {
+		return nil, errs.NewExitError(err.Error())
+	}
+
+	return d.Aliases, nil
+}
+
+// checkSecretAliases returns an error if an alias isn't a valid secret name,
+// or if two secrets share the same alias. Aliases are used as the names of
+// files and environment variables, so they must be as safe as secret names.
+func checkSecretAliases(aliases map[string]string) error {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	seen := make(map[string]string, len(aliases))
+	for _, name := range names {
+		alias := aliases[name]
+		if !pathexp.ValidSecret(alias) || strings.Contains(alias, "*") {
+			return fmt.Errorf("Invalid alias %q for secret %s in .torus.json, aliases must be valid secret names",
+				alias, name)
+		}
+
+		if other, ok := seen[alias]; ok {
+			return fmt.Errorf("Secrets %s and %s cannot both have the alias %s in .torus.json",
+				other, name, alias)
+		}
+		seen[alias] = name
+	}
+
+	return nil
+}
+
+// aliasSecrets returns the secrets with any aliased names replaced. An
+// aliased secret takes the place of a secret already using its alias.
+func aliasSecrets(secrets []apitypes.CredentialEnvelope, aliases map[string]string) []apitypes.CredentialEnvelope {
+	if len(aliases) == 0 {
+		return secrets
+	}
+
+	shadowed := make(map[string]bool)
+	for _, secret := range secrets {
+		if alias, ok := aliases[(*secret.Body).GetName()]; ok {
+			shadowed[alias] = true
+		}
+	}
+
+	out := make([]apitypes.CredentialEnvelope, 0, len(secrets))
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		alias, ok := aliases[name]
+		switch {
+		case ok:
+			var body apitypes.Credential = &aliasedCredential{Credential: *secret.Body, name: alias}
+			secret.Body = &body
+		case shadowed[name]:
+			continue
+		}
+
+		out = append(out, secret)
+	}
+
+	return out
+}
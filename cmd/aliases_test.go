@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+func TestAliasSecrets(t *testing.T) {
+	creds := typedCredentialsHelper()
+
+	t.Run("no aliases", func(t *testing.T) {
+		out := aliasSecrets(creds, nil)
+		if len(out) != len(creds) {
+			t.Errorf("aliasSecrets() expected %d secrets, got %d", len(creds), len(out))
+		}
+	})
+
+	t.Run("renames and shadows", func(t *testing.T) {
+		out := aliasSecrets(creds, map[string]string{
+			"port":    "db_port",
+			"ratio":   "key",
+			"missing": "greeting",
+		})
+
+		got := map[string]*apitypes.CredentialValue{}
+		for _, c := range out {
+			got[(*c.Body).GetName()] = (*c.Body).GetValue()
+		}
+
+		want := map[string]string{"db_port": "5432", "key": "2", "greeting": " say \"hi\" = café"}
+		if len(got) != len(want) || len(out) != len(want) {
+			t.Fatalf("aliasSecrets() expected %d secrets, got %v", len(want), got)
+		}
+		for name, value := range want {
+			if v, ok := got[name]; !ok || v.String() != value {
+				t.Errorf("aliasSecrets() expected %s=%q, got %v", name, value, v)
+			}
+		}
+
+		if (*creds[0].Body).GetName() != "port" {
+			t.Error("aliasSecrets() modified the original secret")
+		}
+	})
+}
+
+func TestCheckSecretAliases(t *testing.T) {
+	tcs := []struct {
+		name    string
+		aliases map[string]string
+		err     bool
+	}{
+		{"none", nil, false},
+		{"valid", map[string]string{"database_url": "pg_url", "port": "db_port"}, false},
+		{"path", map[string]string{"rc": "../../.bashrc"}, true},
+		{"separator", map[string]string{"rc": "a/b"}, true},
+		{"dot", map[string]string{"rc": ".hidden"}, true},
+		{"wildcard", map[string]string{"rc": "*"}, true},
+		{"empty", map[string]string{"rc": ""}, true},
+		{"duplicate", map[string]string{"a": "same", "b": "same"}, true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkSecretAliases(tc.aliases)
+			if tc.err && err == nil {
+				t.Errorf("checkSecretAliases(%v) expected an error", tc.aliases)
+			} else if !tc.err && err != nil {
+				t.Errorf("checkSecretAliases(%v) expected no errors, got %s", tc.aliases, err)
+			}
+		})
+	}
+}
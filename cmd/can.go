@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/juju/ansiterm"
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func init() {
+	can := cli.Command{
+		Name:      "can",
+		Usage:     "Check whether a user or machine can access a secret, and which policies decide it",
+		ArgsUsage: "<username|machine> <crudl> <path>",
+		Category:  "ACCESS CONTROL",
+		Flags:     []cli.Flag{outputFlag},
+		Action:    chain(checkOutputFormat, ensureDaemon, ensureSession, canCmd),
+	}
+
+	Cmds = append(Cmds, can)
+}
+
+// appliedStatement is a policy statement which applies to an identity,
+// through a policy attached to one of its teams.
+type appliedStatement struct {
+	Policy    string
+	Team      string
+	Statement primitive.PolicyStatement
+}
+
+// accessDecision is the result of evaluating statements for an action on a
+// resource.
+type accessDecision struct {
+	Allowed primitive.PolicyAction
+	Denied  primitive.PolicyAction
+
+	// Statements are those which match the resource and cover at least one
+	// of the actions, in the order they were given.
+	Statements []appliedStatement
+}
+
+// Permits returns whether every one of the actions is allowed.
+func (d *accessDecision) Permits(action primitive.PolicyAction) bool {
+	return (d.Allowed&^d.Denied)&action == action
+}
+
+// evaluateAccess decides whether the statements permit the action on the
+// resource. An action is permitted if a statement allows it, and no statement
+// denies it; deny always overrides allow. Variables in statement resources,
+// such as ${org}, are replaced using vars.
+func evaluateAccess(statements []appliedStatement, action primitive.PolicyAction,
+	resource string, vars map[string]string) *accessDecision {
+
+	d := &accessDecision{}
+	for _, s := range statements {
+		if s.Statement.Action&action == 0 {
+			continue
+		}
+
+		if !resourceContains(expandResource(s.Statement.Resource, vars), resource) {
+			continue
+		}
+
+		d.Statements = append(d.Statements, s)
+		if s.Statement.Effect == primitive.PolicyEffectAllow {
+			d.Allowed |= s.Statement.Action & action
+		} else {
+			d.Denied |= s.Statement.Action & action
+		}
+	}
+
+	return d
+}
+
+// expandResource replaces the ${name} variables in a policy resource.
+func expandResource(resource string, vars map[string]string) string {
+	for name, value := range vars {
+		resource = strings.Replace(resource, "${"+name+"}", value, -1)
+	}
+
+	return resource
+}
+
+// resourceContains returns whether a policy resource covers the subject.
+// Both must have the same number of segments. Each segment of the resource
+// is matched like those of a path expression, except that the org and project
+// may also be globbed, as they are in the default policies.
+func resourceContains(resource, subject string) bool {
+	rparts := strings.Split(resource, "/")
+	sparts := strings.Split(subject, "/")
+	if len(rparts) != len(sparts) || rparts[0] != "" {
+		return false
+	}
+
+	for i := 1; i < len(rparts); i++ {
+		if !segmentContains(rparts[i], sparts[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func segmentContains(segment, subject string) bool {
+	parts, err := pathexp.Split("resource", segment)
+	if err != nil {
+		return false
+	}
+
+	for _, p := range parts {
+		switch {
+		case p == "*", p == subject:
+			return true
+		case strings.HasSuffix(p, "*") && pathexp.GlobContains(strings.TrimSuffix(p, "*"), subject):
+			return true
+		}
+	}
+
+	return false
+}
+
+// identityStatements looks up the user or machine with the given name in the
+// org, and returns the statements of every policy attached to its teams,
+// along with the identity used for the ${username} variable.
+func identityStatements(c context.Context, client *api.Client, org *envelope.Org,
+	name string) (string, []appliedStatement, error) {
+
+	var ident string
+	var memberships []envelope.Membership
+
+	profile, err := client.Profiles.ListByName(c, name)
+	switch {
+	case err != nil && !apitypes.IsNotFoundError(err):
+		return "", nil, errs.NewErrorExitError("Could not look up user", err)
+	case err == nil && profile != nil && profile.ID != nil:
+		ident = profile.Body.Username
+		memberships, err = client.Memberships.List(c, org.ID, nil, profile.ID)
+		if err != nil {
+			return "", nil, errs.NewErrorExitError("Could not retrieve teams", err)
+		}
+	default:
+		state := primitive.MachineActiveState
+		machines, err := client.Machines.List(c, org.ID, &state, &name, nil)
+		if err != nil {
+			return "", nil, errs.NewErrorExitError("Could not look up machine", err)
+		}
+		if len(machines) < 1 {
+			return "", nil, errs.NewExitError("No user or machine named " + name + " found.")
+		}
+
+		ident = "machine-" + machines[0].Machine.Body.Name
+		memberships = machines[0].Memberships
+	}
+
+	if len(memberships) < 1 {
+		return "", nil, errs.NewExitError(name + " is not a member of the " + org.Body.Name + " org.")
+	}
+
+	teams, err := client.Teams.GetByOrg(c, org.ID)
+	if err != nil {
+		return "", nil, errs.NewErrorExitError("Could not retrieve teams", err)
+	}
+
+	policies, err := client.Policies.List(c, org.ID, "")
+	if err != nil {
+		return "", nil, errs.NewErrorExitError(policyListFailed, err)
+	}
+
+	attachments, err := client.Policies.AttachmentsList(c, org.ID, nil, nil)
+	if err != nil {
+		return "", nil, errs.NewErrorExitError(policyListFailed, err)
+	}
+
+	return ident, attachedStatements(memberships, teams, policies, attachments), nil
+}
+
+// attachedStatements returns the statements of the policies attached to the
+// teams of the given memberships, ordered by team and policy name.
+func attachedStatements(memberships []envelope.Membership, teams []envelope.Team,
+	policies []envelope.Policy, attachments []envelope.PolicyAttachment) []appliedStatement {
+
+	teamNames := make(map[identity.ID]string, len(teams))
+	for _, t := range teams {
+		teamNames[*t.ID] = t.Body.Name
+	}
+
+	policiesByID := make(map[identity.ID]envelope.Policy, len(policies))
+	for _, p := range policies {
+		policiesByID[*p.ID] = p
+	}
+
+	member := make(map[identity.ID]bool, len(memberships))
+	for _, m := range memberships {
+		member[*m.Body.TeamID] = true
+	}
+
+	out := []appliedStatement{}
+	for _, a := range attachments {
+		policy, ok := policiesByID[*a.Body.PolicyID]
+		if !ok || !member[*a.Body.OwnerID] {
+			continue
+		}
+
+		for _, stmt := range policy.Body.Policy.Statements {
+			out = append(out, appliedStatement{
+				Policy:    policy.Body.Policy.Name,
+				Team:      teamNames[*a.Body.OwnerID],
+				Statement: stmt,
+			})
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Team != out[j].Team {
+			return out[i].Team < out[j].Team
+		}
+		return out[i].Policy < out[j].Policy
+	})
+
+	return out
+}
+
+// splitActions returns each of the actions on its own, in crudl order.
+func splitActions(action primitive.PolicyAction) []primitive.PolicyAction {
+	out := []primitive.PolicyAction{}
+	for a := primitive.PolicyAction(primitive.PolicyActionCreate); a <= primitive.PolicyActionList; a <<= 1 {
+		if action&a != 0 {
+			out = append(out, a)
+		}
+	}
+
+	return out
+}
+
+// canOutput is the structured output of an access check
+type canOutput struct {
+	Identity   string                   `json:"identity"`
+	Path       string                   `json:"path"`
+	Allowed    bool                     `json:"allowed"`
+	Actions    []canActionOutput        `json:"actions"`
+	Statements []appliedStatementOutput `json:"statements"`
+}
+
+// canActionOutput is the decision for a single action
+type canActionOutput struct {
+	Action   string `json:"action"`
+	Decision string `json:"decision"`
+}
+
+// appliedStatementOutput is the structured output of a statement, and the
+// policy and team it applies through
+type appliedStatementOutput struct {
+	Policy string `json:"policy"`
+	Team   string `json:"team"`
+	statementOutput
+}
+
+func canCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 3 {
+		msg := "identity, permissions, and path are required."
+		if len(args) > 3 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	name := args[0]
+
+	action, err := parseAction(args[1])
+	if err != nil {
+		return err
+	}
+
+	// Secret names are stored in lowercase, but are often given as they're
+	// used in the environment.
+	pe, secretName, err := parseRawPath(strings.ToLower(args[2]))
+	if err != nil {
+		return err
+	}
+	resource := pe.String() + "/" + *secretName
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := client.Orgs.GetByName(c, pe.Org.String())
+	if err != nil {
+		return errs.NewErrorExitError("Unable to lookup org.", err)
+	}
+	if org == nil {
+		return errs.NewExitError("Org not found.")
+	}
+
+	ident, statements, err := identityStatements(c, client, org, name)
+	if err != nil {
+		return err
+	}
+
+	vars := map[string]string{"org": org.Body.Name, "username": ident}
+	d := evaluateAccess(statements, action, resource, vars)
+
+	out := canOutput{
+		Identity:   name,
+		Path:       resource,
+		Allowed:    d.Permits(action),
+		Statements: []appliedStatementOutput{},
+	}
+	for _, a := range splitActions(action) {
+		decision := "allowed"
+		switch {
+		case d.Denied&a != 0:
+			decision = "denied"
+		case d.Allowed&a == 0:
+			decision = "not allowed"
+		}
+		out.Actions = append(out.Actions, canActionOutput{Action: a.String(), Decision: decision})
+	}
+	for _, s := range d.Statements {
+		out.Statements = append(out.Statements, appliedStatementOutput{
+			Policy: s.Policy,
+			Team:   s.Team,
+			statementOutput: statementOutput{
+				Effect:   s.Statement.Effect.String(),
+				Action:   s.Statement.Action.ShortString(),
+				Resource: s.Statement.Resource,
+			},
+		})
+	}
+
+	if structuredOutput(ctx) {
+		if err := writeOutput(ctx, os.Stdout, out); err != nil {
+			return err
+		}
+	} else {
+		displayAccessDecision(&out, action)
+	}
+
+	if !out.Allowed {
+		return errs.NewExitError(fmt.Sprintf("%s cannot %s %s", name, action.String(), resource))
+	}
+
+	return nil
+}
+
+func displayAccessDecision(out *canOutput, action primitive.PolicyAction) {
+	fmt.Println("")
+	w := ansiterm.NewTabWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\n", ui.BoldString("Action"), ui.BoldString("Decision"))
+	for _, a := range out.Actions {
+		decision := ui.ColorString(ui.Green, a.Decision)
+		if a.Decision != "allowed" {
+			decision = ui.ColorString(ui.Red, a.Decision)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", a.Action, decision)
+	}
+	w.Flush()
+
+	fmt.Println("")
+	if len(out.Statements) == 0 {
+		fmt.Println("No policy statements apply to this path.")
+	} else {
+		w = ansiterm.NewTabWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", ui.BoldString("Effect"), ui.BoldString("Actions"),
+			ui.BoldString("Resource"), ui.BoldString("Policy"), ui.BoldString("Team"))
+		for _, s := range out.Statements {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Effect, s.Action, s.Resource, s.Policy, s.Team)
+		}
+		w.Flush()
+	}
+	fmt.Println("")
+
+	if out.Allowed {
+		fmt.Printf("%s can %s %s\n", out.Identity, action.String(), out.Path)
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestResourceContains(t *testing.T) {
+	tcs := []struct {
+		resource string
+		subject  string
+		want     bool
+	}{
+		{"/o/*/*/*/*/*/*", "/o/p/e/s/*/*/name", true},
+		{"/o/p/e/s/*/*/name", "/o/p/e/s/*/*/name", true},
+		{"/o/p/e/s/*/*/name", "/o/p/e/s/*/*/other", false},
+		{"/o/p/[dev|prod*]/*/*/*/*", "/o/p/production/s/*/*/name", true},
+		{"/o/p/[dev|prod*]/*/*/*/*", "/o/p/staging/s/*/*/name", false},
+		{"/o/p/e/s/*/*/DB_*", "/o/p/e/s/*/*/DB_URL", true},
+		{"/o/p/e/s/ci/*/*", "/o/p/e/s/*/*/name", false},
+		{"/o/*", "/o/p/e/s/*/*/name", false},
+		{"/x/*/*/*/*/*/*", "/o/p/e/s/*/*/name", false},
+		{"teams:*", "/o/p/e/s/*/*/name", false},
+	}
+
+	for _, tc := range tcs {
+		if got := resourceContains(tc.resource, tc.subject); got != tc.want {
+			t.Errorf("resourceContains(%q, %q) expected %t, got %t", tc.resource, tc.subject, tc.want, got)
+		}
+	}
+}
+
+func TestEvaluateAccess(t *testing.T) {
+	stmt := func(team string, effect primitive.PolicyEffect, action primitive.PolicyAction, resource string) appliedStatement {
+		return appliedStatement{
+			Policy:    team + "-policy",
+			Team:      team,
+			Statement: primitive.PolicyStatement{Effect: effect, Action: action, Resource: resource},
+		}
+	}
+
+	allow := primitive.PolicyEffect(primitive.PolicyEffectAllow)
+	deny := primitive.PolicyEffect(primitive.PolicyEffectDeny)
+	read := primitive.PolicyAction(primitive.PolicyActionRead)
+	list := primitive.PolicyAction(primitive.PolicyActionList)
+	all := primitive.PolicyAction(primitive.PolicyActionCreate | primitive.PolicyActionRead |
+		primitive.PolicyActionUpdate | primitive.PolicyActionDelete | primitive.PolicyActionList)
+
+	statements := []appliedStatement{
+		stmt("member", allow, all, "/${org}/*/[dev-${username}|dev-@]/*/*/*/*"),
+		stmt("ops", allow, read|list, "/${org}/*/*/*/*/*/*"),
+		stmt("ops", deny, read, "/${org}/*/production/*/*/*/*"),
+		stmt("ops", allow, all, "/${org}/*/*/*"),
+	}
+	vars := map[string]string{"org": "o", "username": "alice"}
+
+	t.Run("allowed by a variable resource", func(t *testing.T) {
+		d := evaluateAccess(statements, all, "/o/p/dev-alice/s/*/*/name", vars)
+		if !d.Permits(all) {
+			t.Errorf("expected all actions to be permitted, got allowed %s", d.Allowed.String())
+		}
+		if len(d.Statements) != 2 {
+			t.Errorf("expected 2 contributing statements, got %d", len(d.Statements))
+		}
+	})
+
+	t.Run("deny overrides allow", func(t *testing.T) {
+		d := evaluateAccess(statements, read|list, "/o/p/production/s/*/*/name", vars)
+		if d.Permits(read) {
+			t.Error("expected read to be denied")
+		}
+		if !d.Permits(list) {
+			t.Error("expected list to be permitted")
+		}
+		if d.Permits(read | list) {
+			t.Error("expected read and list together to not be permitted")
+		}
+		if len(d.Statements) != 2 {
+			t.Errorf("expected 2 contributing statements, got %d", len(d.Statements))
+		}
+	})
+
+	t.Run("not allowed without a statement", func(t *testing.T) {
+		d := evaluateAccess(statements, primitive.PolicyActionUpdate, "/o/p/dev-bob/s/*/*/name", vars)
+		if d.Permits(primitive.PolicyActionUpdate) || d.Denied != 0 {
+			t.Error("expected update to not be allowed, nor denied")
+		}
+		if len(d.Statements) != 0 {
+			t.Errorf("expected no contributing statements, got %d", len(d.Statements))
+		}
+	})
+}
+
+func TestSplitActions(t *testing.T) {
+	got := splitActions(primitive.PolicyActionCreate | primitive.PolicyActionList)
+	if len(got) != 2 || got[0] != primitive.PolicyActionCreate || got[1] != primitive.PolicyActionList {
+		t.Errorf("splitActions() expected create and list, got %v", got)
+	}
+}
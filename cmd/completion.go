@@ -0,0 +1,550 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/dirprefs"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/prefs"
+)
+
+// completionCacheTTL is how long names fetched for completions are reused.
+const completionCacheTTL = time.Minute
+
+// completionTimeout bounds the time spent fetching names for a completion, so
+// a slow or missing daemon doesn't hang the shell.
+const completionTimeout = 3 * time.Second
+
+// completionScripts register completions for each shell. They are given the
+// quoted path of the torus executable, which is run with the words of the
+// command line up to and including the one being completed.
+var completionScripts = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(`_torus_complete() {
+  local IFS=$'\n'
+  COMPREPLY=( $({{.}} __complete "${COMP_WORDS[@]:1:$COMP_CWORD}" 2>/dev/null) )
+}
+complete -o default -F _torus_complete torus
+`)),
+	"zsh": template.Must(template.New("zsh").Parse(`_torus() {
+  local -a completions
+  completions=( ${(f)"$({{.}} __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"} )
+  compadd -a completions
+}
+compdef _torus torus
+`)),
+	"fish": template.Must(template.New("fish").Parse(`function __torus_complete
+  set -l tokens (commandline -opc) (commandline -ct)
+  {{.}} __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c torus -f -a '(__torus_complete)'
+`)),
+}
+
+// completer returns the names which can complete a word.
+type completer func(r *completionResolver) ([]string, error)
+
+// flagCompleters complete the values of flags, by the flag's long name.
+var flagCompleters = map[string]completer{
+	"org":         completeOrgs,
+	"project":     completeProjects,
+	"environment": completeEnvs,
+	"service":     completeServices,
+	"team":        completeTeams,
+}
+
+// argCompleters complete the first argument of commands, by the command's
+// full name.
+var argCompleters = map[string]completer{
+	"completion": completeShells,
+	"hook":       completeShells,
+	"set":        completeSecretNames,
+	"unset":      completeSecretNames,
+	"generate":   completeSecretNames,
+	"history":    completeSecretNames,
+	"rollback":   completeSecretNames,
+}
+
+func init() {
+	completion := cli.Command{
+		Name:      "completion",
+		Usage:     "Output a shell script which completes torus commands",
+		ArgsUsage: "<" + strings.Join(hookShells, "|") + ">",
+		Category:  "SYSTEM",
+		Action:    chain(completionCmd),
+	}
+
+	complete := cli.Command{
+		Name:            "__complete",
+		Usage:           "Output the completions for a partial command line",
+		Hidden:          true,
+		SkipFlagParsing: true,
+		Action:          completeCmd,
+	}
+
+	Cmds = append(Cmds, completion, complete)
+}
+
+func completionCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		return errs.NewUsageExitError("A shell is required", ctx)
+	}
+
+	tmpl, ok := completionScripts[args[0]]
+	if !ok {
+		return errs.NewUsageExitError("Unsupported shell: "+args[0], ctx)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		exe = ctx.App.Name
+	}
+
+	return tmpl.Execute(os.Stdout, shellQuote(args[0], exe))
+}
+
+// completeCmd prints the completions for the given words, one per line. Any
+// errors are swallowed, as there is nowhere to show them while completing.
+func completeCmd(ctx *cli.Context) error {
+	words := []string(ctx.Args())
+	if len(words) == 0 {
+		words = []string{""}
+	}
+
+	req := parseCompletionWords(Cmds, words)
+	candidates, comp := staticCompletions(Cmds, req)
+	if comp != nil {
+		r, err := newCompletionResolver(req.flags)
+		if err != nil {
+			return nil
+		}
+		defer r.Close()
+
+		candidates, err = comp(r)
+		if err != nil {
+			return nil
+		}
+	}
+
+	for _, c := range candidates {
+		if strings.HasPrefix(c, req.partial) {
+			fmt.Println(c)
+		}
+	}
+
+	return nil
+}
+
+// completionRequest describes the word being completed.
+type completionRequest struct {
+	command *cli.Command      // The command the words lead to, or nil
+	name    string            // The command's full name
+	flag    string            // The flag whose value is being completed
+	arg     int               // The number of arguments before the word
+	partial string            // The word being completed
+	flags   map[string]string // Values of the flags given, by long name
+}
+
+// parseCompletionWords walks the commands using the given words, the last of
+// which is the one being completed.
+func parseCompletionWords(cmds []cli.Command, words []string) *completionRequest {
+	req := &completionRequest{
+		partial: words[len(words)-1],
+		flags:   make(map[string]string),
+	}
+
+	for _, w := range words[:len(words)-1] {
+		if req.flag != "" {
+			req.flags[req.flag] = w
+			req.flag = ""
+			continue
+		}
+
+		if strings.HasPrefix(w, "-") && w != "-" && w != "--" && req.command != nil {
+			parts := strings.SplitN(strings.TrimLeft(w, "-"), "=", 2)
+			f, name := findCompletionFlag(req.command.Flags, parts[0])
+			switch {
+			case f == nil:
+			case len(parts) == 2:
+				req.flags[name] = parts[1]
+			case takesValue(f):
+				req.flag = name
+			}
+			continue
+		}
+
+		subcommands := cmds
+		if req.command != nil {
+			subcommands = req.command.Subcommands
+		}
+		if sub := findCompletionCommand(subcommands, w); sub != nil && req.arg == 0 {
+			req.command = sub
+			req.name = strings.TrimSpace(req.name + " " + sub.Name)
+			continue
+		}
+
+		req.arg++
+	}
+
+	return req
+}
+
+// staticCompletions returns the commands or flags which can complete the
+// word, or the completer for names which must be fetched.
+func staticCompletions(cmds []cli.Command, req *completionRequest) ([]string, completer) {
+	if req.flag != "" {
+		return nil, flagCompleters[req.flag]
+	}
+
+	if req.command != nil && strings.HasPrefix(req.partial, "-") {
+		var names []string
+		for _, f := range req.command.Flags {
+			for _, n := range flagNames(f) {
+				names = append(names, prefixFor(n)+n)
+			}
+		}
+		return names, nil
+	}
+
+	subcommands := cmds
+	if req.command != nil {
+		subcommands = req.command.Subcommands
+	}
+	if len(subcommands) > 0 && req.arg == 0 {
+		var names []string
+		for _, c := range subcommands {
+			if !c.Hidden {
+				names = append(names, c.Name)
+			}
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	if req.arg == 0 {
+		return nil, argCompleters[req.name]
+	}
+
+	return nil, nil
+}
+
+func findCompletionCommand(cmds []cli.Command, name string) *cli.Command {
+	for i, c := range cmds {
+		if c.HasName(name) {
+			return &cmds[i]
+		}
+	}
+
+	return nil
+}
+
+// findCompletionFlag returns the flag with the given name, and its long name.
+func findCompletionFlag(flags []cli.Flag, name string) (cli.Flag, string) {
+	for _, f := range flags {
+		names := flagNames(f)
+		for _, n := range names {
+			if n == name {
+				return f, names[0]
+			}
+		}
+	}
+
+	return nil, ""
+}
+
+func flagNames(f cli.Flag) []string {
+	var names []string
+	for _, n := range strings.Split(f.GetName(), ",") {
+		names = append(names, strings.TrimSpace(n))
+	}
+
+	return names
+}
+
+func takesValue(f cli.Flag) bool {
+	switch f.(type) {
+	case cli.BoolFlag, cli.BoolTFlag:
+		return false
+	default:
+		return true
+	}
+}
+
+// completionResolver fetches names for completions, within the org and
+// project given on the command line, or from context. Names are cached for a
+// short time, as completions are requested many times in a row.
+type completionResolver struct {
+	c       context.Context
+	cancel  context.CancelFunc
+	client  *api.Client
+	org     string
+	project string
+
+	cachePath string
+	cache     map[string]completionCacheEntry
+	dirty     bool
+}
+
+type completionCacheEntry struct {
+	Names   []string  `json:"names"`
+	Fetched time.Time `json:"fetched"`
+}
+
+func newCompletionResolver(flags map[string]string) (*completionResolver, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	c, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	r := &completionResolver{
+		c:         c,
+		cancel:    cancel,
+		client:    api.NewClient(cfg),
+		org:       completionContext(flags, "org"),
+		project:   completionContext(flags, "project"),
+		cachePath: filepath.Join(cfg.TorusRoot, "completion_cache.json"),
+		cache:     make(map[string]completionCacheEntry),
+	}
+
+	if raw, err := ioutil.ReadFile(r.cachePath); err == nil {
+		json.Unmarshal(raw, &r.cache)
+	}
+
+	return r, nil
+}
+
+// completionContext returns the value of the flag with the given name, taken
+// from the command line, its environment variable, the .torus.json file or
+// the .torusrc defaults, in that order.
+func completionContext(flags map[string]string, name string) string {
+	if v := flags[name]; v != "" {
+		return v
+	}
+	if v := os.Getenv("TORUS_" + strings.ToUpper(name)); v != "" {
+		return v
+	}
+
+	p, err := prefs.NewPreferences()
+	if err != nil || !p.Core.Context {
+		return ""
+	}
+
+	if d, err := dirprefs.Load(true); err == nil {
+		switch {
+		case name == "org" && d.Organization != "":
+			return d.Organization
+		case name == "project" && d.Project != "":
+			return d.Project
+		}
+	}
+
+	if name == "org" {
+		return p.Defaults.Organization
+	}
+	return p.Defaults.Project
+}
+
+// Close saves any newly fetched names.
+func (r *completionResolver) Close() error {
+	r.cancel()
+	if !r.dirty {
+		return nil
+	}
+
+	raw, err := json.Marshal(r.cache)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.cachePath, raw, 0600)
+}
+
+// cached returns the names cached under key, fetching them if they are
+// missing or stale.
+func (r *completionResolver) cached(key string, fetch func() ([]string, error)) ([]string, error) {
+	if e, ok := r.cache[key]; ok && time.Since(e.Fetched) < completionCacheTTL {
+		return e.Names, nil
+	}
+
+	names, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	for k, e := range r.cache {
+		if now.Sub(e.Fetched) >= completionCacheTTL {
+			delete(r.cache, k)
+		}
+	}
+	r.cache[key] = completionCacheEntry{Names: names, Fetched: now}
+	r.dirty = true
+
+	return names, nil
+}
+
+func (r *completionResolver) orgID() (*identity.ID, error) {
+	if r.org == "" {
+		return nil, errs.NewExitError("No org to complete within")
+	}
+
+	org, err := r.client.Orgs.GetByName(r.c, r.org)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, errs.NewExitError("Org not found")
+	}
+
+	return org.ID, nil
+}
+
+func (r *completionResolver) projectIDs() (*identity.ID, *identity.ID, error) {
+	orgID, err := r.orgID()
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.project == "" {
+		return nil, nil, errs.NewExitError("No project to complete within")
+	}
+
+	projects, err := r.client.Projects.Search(r.c, []identity.ID{*orgID}, []string{r.project})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(projects) < 1 {
+		return nil, nil, errs.NewExitError("Project not found")
+	}
+
+	return orgID, projects[0].ID, nil
+}
+
+func completeShells(r *completionResolver) ([]string, error) {
+	return hookShells, nil
+}
+
+func completeOrgs(r *completionResolver) ([]string, error) {
+	return r.cached("orgs", func() ([]string, error) {
+		orgs, err := r.client.Orgs.List(r.c)
+		if err != nil {
+			return nil, err
+		}
+		return toOrgNames(orgs), nil
+	})
+}
+
+func completeProjects(r *completionResolver) ([]string, error) {
+	return r.cached("projects/"+r.org, func() ([]string, error) {
+		orgID, err := r.orgID()
+		if err != nil {
+			return nil, err
+		}
+
+		projects, err := r.client.Projects.List(r.c, orgID)
+		if err != nil {
+			return nil, err
+		}
+		return toProjectNames(projects), nil
+	})
+}
+
+func completeEnvs(r *completionResolver) ([]string, error) {
+	return r.cached("envs/"+r.org+"/"+r.project, func() ([]string, error) {
+		orgID, projectID, err := r.projectIDs()
+		if err != nil {
+			return nil, err
+		}
+
+		envs, err := listEnvs(&r.c, r.client, orgID, projectID, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, len(envs))
+		for i, e := range envs {
+			names[i] = e.Body.Name
+		}
+		return names, nil
+	})
+}
+
+func completeServices(r *completionResolver) ([]string, error) {
+	return r.cached("services/"+r.org+"/"+r.project, func() ([]string, error) {
+		orgID, projectID, err := r.projectIDs()
+		if err != nil {
+			return nil, err
+		}
+
+		services, err := listServices(&r.c, r.client, orgID, projectID, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, len(services))
+		for i, s := range services {
+			names[i] = s.Body.Name
+		}
+		return names, nil
+	})
+}
+
+func completeTeams(r *completionResolver) ([]string, error) {
+	return r.cached("teams/"+r.org, func() ([]string, error) {
+		orgID, err := r.orgID()
+		if err != nil {
+			return nil, err
+		}
+
+		teams, err := r.client.Teams.GetByOrg(r.c, orgID)
+		if err != nil {
+			return nil, err
+		}
+		return toTeamNames(teams), nil
+	})
+}
+
+// completeSecretNames completes the names of the secrets in any environment
+// or service of the project. Only the names are cached.
+func completeSecretNames(r *completionResolver) ([]string, error) {
+	return r.cached("secrets/"+r.org+"/"+r.project, func() ([]string, error) {
+		if r.org == "" || r.project == "" {
+			return nil, errs.NewExitError("No project to complete within")
+		}
+
+		path := fmt.Sprintf("/%s/%s/*/*/*/*", r.org, r.project)
+		creds, err := r.client.Credentials.Search(r.c, path, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		seen := make(map[string]bool)
+		var names []string
+		for _, cred := range creds {
+			name := (*cred.Body).GetName()
+			if (*cred.Body).GetValue().IsUnset() {
+				continue
+			}
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+		return names, nil
+	})
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestCompletions(t *testing.T) {
+	cmds := []cli.Command{
+		{
+			Name:  "view",
+			Flags: []cli.Flag{stdOrgFlag, stdProjectFlag, cli.BoolFlag{Name: "verbose, v"}},
+		},
+		{Name: "set", Flags: []cli.Flag{stdOrgFlag}},
+		{
+			Name: "teams",
+			Subcommands: []cli.Command{
+				{Name: "list", Flags: []cli.Flag{stdOrgFlag}},
+				{Name: "members"},
+			},
+		},
+		{Name: "__complete", Hidden: true},
+	}
+
+	tcs := []struct {
+		name    string
+		words   []string
+		want    []string
+		command string
+		flag    string
+		arg     int
+		flags   map[string]string
+	}{
+		{"commands", []string{""}, []string{"set", "teams", "view"}, "", "", 0, nil},
+		{"subcommands", []string{"teams", "m"}, []string{"list", "members"}, "teams", "", 0, nil},
+		{"flags", []string{"view", "-"}, []string{"--org", "-o", "--project", "-p", "--verbose", "-v"}, "view", "", 0, nil},
+		{"flag value", []string{"view", "-o", "myorg", "--project", ""}, nil, "view", "project", 0, map[string]string{"org": "myorg"}},
+		{"flag value with equals", []string{"teams", "list", "--org=myorg", ""}, nil, "teams list", "", 0, map[string]string{"org": "myorg"}},
+		{"after bool flag", []string{"view", "-v", "-p", "x"}, nil, "view", "project", 0, map[string]string{}},
+		{"argument", []string{"set", "-o", "myorg", "na"}, nil, "set", "", 0, map[string]string{"org": "myorg"}},
+		{"second argument", []string{"set", "name", ""}, nil, "set", "", 1, map[string]string{}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			req := parseCompletionWords(cmds, tc.words)
+			names, _ := staticCompletions(cmds, req)
+			if !reflect.DeepEqual(names, tc.want) {
+				t.Errorf("staticCompletions() expected %v, got %v", tc.want, names)
+			}
+
+			if req.name != tc.command || req.flag != tc.flag || req.arg != tc.arg {
+				t.Errorf("parseCompletionWords() expected command %q, flag %q and arg %d, got %q, %q and %d",
+					tc.command, tc.flag, tc.arg, req.name, req.flag, req.arg)
+			}
+
+			if tc.flags != nil && !reflect.DeepEqual(req.flags, tc.flags) {
+				t.Errorf("parseCompletionWords() expected flags %v, got %v", tc.flags, req.flags)
+			}
+		})
+	}
+}
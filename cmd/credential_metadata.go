@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+// credentialOwners returns the names of the teams that own the given
+// credentials, keyed by team ID.
+func credentialOwners(c context.Context, client *api.Client, creds []apitypes.CredentialEnvelope) (map[identity.ID]string, error) {
+	owners := make(map[identity.ID]string)
+	seenOrgs := make(map[identity.ID]bool)
+	for _, cred := range creds {
+		m := (*cred.Body).GetMetadata()
+		orgID := (*cred.Body).GetOrgID()
+		if m == nil || m.OwnerID == nil || orgID == nil || seenOrgs[*orgID] {
+			continue
+		}
+		seenOrgs[*orgID] = true
+
+		teams, err := client.Teams.GetByOrg(c, orgID)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range teams {
+			owners[*t.ID] = t.Body.Name
+		}
+	}
+
+	return owners, nil
+}
+
+// metadataExpired returns whether the credential described by the metadata has
+// expired as of the given time.
+func metadataExpired(m *primitive.CredentialMetadata, now time.Time) bool {
+	return m != nil && m.ExpiresAt != nil && !now.Before(*m.ExpiresAt)
+}
+
+// formatCredentialMetadata returns a single line describing the metadata,
+// or an empty string if there is none. Owners are displayed by name if they
+// are in owners, and by ID otherwise.
+func formatCredentialMetadata(m *primitive.CredentialMetadata, owners map[identity.ID]string, now time.Time) string {
+	if m == nil {
+		return ""
+	}
+
+	parts := []string{}
+	if m.Description != "" {
+		parts = append(parts, m.Description)
+	}
+	if m.OwnerID != nil {
+		owner, ok := owners[*m.OwnerID]
+		if !ok {
+			owner = m.OwnerID.String()
+		}
+		parts = append(parts, "owner: "+owner)
+	}
+	if len(m.Tags) > 0 {
+		parts = append(parts, "tags: "+strings.Join(m.Tags, ", "))
+	}
+	if m.ExpiresAt != nil {
+		date := m.ExpiresAt.Format("2006-01-02")
+		if metadataExpired(m, now) {
+			parts = append(parts, ui.ColorString(ui.Red, "expired: "+date))
+		} else {
+			parts = append(parts, "expires: "+date)
+		}
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// secretOutput is the structured output of a secret
+type secretOutput struct {
+	Name     string                `json:"name"`
+	Value    interface{}           `json:"value"`
+	Path     string                `json:"path"`
+	Metadata *secretMetadataOutput `json:"metadata,omitempty"`
+}
+
+// secretMetadataOutput is the structured output of a secret's metadata
+type secretMetadataOutput struct {
+	Description string     `json:"description,omitempty"`
+	Owner       string     `json:"owner,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Expired     bool       `json:"expired"`
+}
+
+// newSecretOutput returns the structured output of the secret. Values keep
+// their type, and owners are named as in formatCredentialMetadata.
+func newSecretOutput(cred apitypes.CredentialEnvelope, owners map[identity.ID]string, now time.Time) secretOutput {
+	body := *cred.Body
+	out := secretOutput{
+		Name:     body.GetName(),
+		Path:     displayPathExp(body.GetPathExp()) + "/" + body.GetName(),
+		Metadata: newSecretMetadataOutput(body.GetMetadata(), owners, now),
+	}
+
+	if raw, err := body.GetValue().Raw(); err == nil {
+		out.Value = raw
+	}
+
+	return out
+}
+
+// newSecretMetadataOutput returns the structured output of the metadata, or
+// nil if there is none.
+func newSecretMetadataOutput(m *primitive.CredentialMetadata, owners map[identity.ID]string,
+	now time.Time) *secretMetadataOutput {
+
+	if m == nil {
+		return nil
+	}
+
+	out := &secretMetadataOutput{
+		Description: m.Description,
+		Tags:        m.Tags,
+		ExpiresAt:   m.ExpiresAt,
+		Expired:     metadataExpired(m, now),
+	}
+	if m.OwnerID != nil {
+		owner, ok := owners[*m.OwnerID]
+		if !ok {
+			owner = m.OwnerID.String()
+		}
+		out.Owner = owner
+	}
+
+	return out
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/prefs"
+	"github.com/manifoldco/torus-cli/primitive"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func TestFormatCredentialMetadata(t *testing.T) {
+	ui.Init(&prefs.Preferences{})
+
+	team, err := identity.DecodeFromString("0f000000000000000000000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := identity.DecodeFromString("0f000000000000000000000000002")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	future := now.AddDate(0, 1, 0)
+	past := now.AddDate(0, -1, 0)
+	owners := map[identity.ID]string{team: "ops"}
+
+	tcs := []struct {
+		name     string
+		metadata *primitive.CredentialMetadata
+		want     string
+	}{
+		{"none", nil, ""},
+		{"empty", &primitive.CredentialMetadata{}, ""},
+		{
+			"all",
+			&primitive.CredentialMetadata{Description: "Primary DB", OwnerID: &team, Tags: []string{"db", "prod"}, ExpiresAt: &future},
+			"Primary DB; owner: ops; tags: db, prod; expires: 2017-07-01",
+		},
+		{"unknown owner", &primitive.CredentialMetadata{OwnerID: &other}, "owner: " + other.String()},
+		{"expired", &primitive.CredentialMetadata{ExpiresAt: &past}, ui.ColorString(ui.Red, "expired: 2017-05-01")},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := formatCredentialMetadata(tc.metadata, owners, now)
+			if got != tc.want {
+				t.Errorf("formatCredentialMetadata() expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/juju/ansiterm"
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func init() {
+	diff := cli.Command{
+		Name:      "diff",
+		Usage:     "Compare the secrets of two environments or services",
+		ArgsUsage: "<path> <path>",
+		Category:  "SECRETS",
+		Flags: []cli.Flag{
+			orgFlag("Use this organization for paths that don't include one.", false),
+			projectFlag("Use this project for paths that don't include one.", false),
+			serviceFlag("Use this service for paths that don't include one.", "default", false),
+			cli.BoolFlag{
+				Name:  "show-values",
+				Usage: "Display the values of secrets that differ",
+			},
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			checkRequiredFlags, diffCmd,
+		),
+	}
+
+	Cmds = append(Cmds, diff)
+}
+
+func diffCmd(ctx *cli.Context) error {
+	if err := argCheck(ctx, 2, 2); err != nil {
+		return err
+	}
+
+	args := ctx.Args()
+	a, err := parseServicePath(ctx, args[0])
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	b, err := parseServicePath(ctx, args[1])
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	secrets, err := fetchServicePaths(c, client, a, b)
+	if err != nil {
+		return err
+	}
+
+	diffs := diffSecrets(secrets[0], secrets[1])
+
+	fmt.Printf("Comparing %s to %s\n\n", a, b)
+	if len(diffs) == 0 {
+		fmt.Println("No differences found.")
+		return nil
+	}
+
+	writeDiff(os.Stdout, diffs, ctx.Bool("show-values"))
+
+	return errs.NewExitError(fmt.Sprintf("%d secret%s differ.", len(diffs), plural(len(diffs))))
+}
+
+// servicePath identifies the secrets of a single service within an
+// environment.
+type servicePath struct {
+	Org     string
+	Project string
+	Env     string
+	Service string
+}
+
+func (p *servicePath) String() string {
+	return strings.Join([]string{"", p.Org, p.Project, p.Env, p.Service}, "/")
+}
+
+// parseServicePath parses either a full path (/org/project/env/service), or
+// a path relative to the org and project flags (env or env/service). If no
+// service is given, the service flag is used.
+func parseServicePath(ctx *cli.Context, raw string) (*servicePath, error) {
+	p := &servicePath{
+		Org:     ctx.String("org"),
+		Project: ctx.String("project"),
+		Service: ctx.String("service"),
+	}
+
+	var parts []string
+	if strings.HasPrefix(raw, "/") {
+		parts = strings.Split(raw[1:], "/")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("Invalid path %s: expected /org/project/environment/service", raw)
+		}
+
+		p.Org, p.Project, p.Env, p.Service = parts[0], parts[1], parts[2], parts[3]
+	} else {
+		parts = strings.Split(raw, "/")
+		switch len(parts) {
+		case 1:
+			p.Env = parts[0]
+		case 2:
+			p.Env, p.Service = parts[0], parts[1]
+		default:
+			return nil, fmt.Errorf("Invalid path %s: expected environment or environment/service", raw)
+		}
+
+		if p.Org == "" || p.Project == "" {
+			return nil, fmt.Errorf("An org and project are required for the path %s", raw)
+		}
+	}
+
+	for _, part := range []string{p.Org, p.Project, p.Env, p.Service} {
+		if !pathexp.ValidSlug(part) {
+			return nil, fmt.Errorf("Invalid path %s: %q is not a valid name", raw, part)
+		}
+	}
+
+	return p, nil
+}
+
+// fetchServicePaths retrieves the compacted secrets for each of the given
+// paths, as seen by the current identity. References within their values are
+// not resolved, so they are compared and copied as they were set.
+func fetchServicePaths(c context.Context, client *api.Client, paths ...*servicePath) ([][]apitypes.CredentialEnvelope, error) {
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return nil, err
+	}
+	identity := deriveIdentity(session)
+
+	s, p := spinner("Decrypting credentials")
+	s.Start()
+	defer s.Stop()
+
+	results := make([][]apitypes.CredentialEnvelope, len(paths))
+	for i, sp := range paths {
+		path, err := deriveExplicitPathExp(sp.Org, sp.Project, sp.Env, sp.Service, identity)
+		if err != nil {
+			return nil, errs.NewErrorExitError("Error deriving credential path", err)
+		}
+
+		results[i], err = fetchCompactedSecrets(c, client, path, false, p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+type diffKind int
+
+const (
+	diffRemoved diffKind = iota
+	diffAdded
+	diffChanged
+)
+
+// secretDiff is a single difference between two sets of secrets. A is nil for
+// added secrets, and B is nil for removed secrets.
+type secretDiff struct {
+	Name string
+	Kind diffKind
+	A    *apitypes.CredentialValue
+	B    *apitypes.CredentialValue
+}
+
+// diffSecrets returns the differences between the secrets in a and b, sorted
+// by name.
+func diffSecrets(a, b []apitypes.CredentialEnvelope) []secretDiff {
+	valuesA := secretValues(a)
+	valuesB := secretValues(b)
+
+	diffs := []secretDiff{}
+	for name, va := range valuesA {
+		vb, ok := valuesB[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, secretDiff{Name: name, Kind: diffRemoved, A: va})
+		case !credentialValuesEqual(va, vb):
+			diffs = append(diffs, secretDiff{Name: name, Kind: diffChanged, A: va, B: vb})
+		}
+	}
+
+	for name, vb := range valuesB {
+		if _, ok := valuesA[name]; !ok {
+			diffs = append(diffs, secretDiff{Name: name, Kind: diffAdded, B: vb})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+
+	return diffs
+}
+
+func secretValues(secrets []apitypes.CredentialEnvelope) map[string]*apitypes.CredentialValue {
+	values := make(map[string]*apitypes.CredentialValue)
+	for _, secret := range secrets {
+		values[(*secret.Body).GetName()] = (*secret.Body).GetValue()
+	}
+
+	return values
+}
+
+// credentialValuesEqual returns whether two values are the same, including
+// their type, so a number and a string of the same digits are not equal.
+func credentialValuesEqual(a, b *apitypes.CredentialValue) bool {
+	ra, _ := a.Raw()
+	rb, _ := b.Raw()
+
+	return a.String() == b.String() && fmt.Sprintf("%T", ra) == fmt.Sprintf("%T", rb)
+}
+
+// writeDiff writes each difference on its own line, prefixed by - for secrets
+// only in the first path, + for secrets only in the second, and ~ for secrets
+// whose values differ. Values are only displayed when showValues is true.
+func writeDiff(w io.Writer, diffs []secretDiff, showValues bool) {
+	tw := ansiterm.NewTabWriter(w, 2, 0, 2, ' ', 0)
+
+	for _, d := range diffs {
+		var sign, value string
+		switch d.Kind {
+		case diffRemoved:
+			sign = ui.ColorString(ui.Red, "-")
+			value = diffValue(d.A)
+		case diffAdded:
+			sign = ui.ColorString(ui.Green, "+")
+			value = diffValue(d.B)
+		case diffChanged:
+			sign = ui.ColorString(ui.Yellow, "~")
+			value = diffValue(d.A) + " => " + diffValue(d.B)
+		}
+
+		if showValues {
+			fmt.Fprintf(tw, "%s %s\t=\t%s\n", sign, ui.BoldString(d.Name), value)
+		} else {
+			fmt.Fprintf(tw, "%s %s\n", sign, ui.BoldString(d.Name))
+		}
+	}
+
+	tw.Flush()
+}
+
+func diffValue(v *apitypes.CredentialValue) string {
+	value := v.String()
+	if v.IsBinary() {
+		return fmt.Sprintf("<binary, %d bytes>", len(value))
+	}
+	if strings.Contains(value, " ") {
+		return fmt.Sprintf("%q", value)
+	}
+
+	return value
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+func TestDiffSecrets(t *testing.T) {
+	creds, _ := viewCredentialsHelper(t)
+
+	changed := func(name string, value *apitypes.CredentialValue) apitypes.CredentialEnvelope {
+		var body apitypes.Credential = &apitypes.CredentialV2{
+			State:          "set",
+			BaseCredential: apitypes.BaseCredential{Name: name, Value: value},
+		}
+		return apitypes.CredentialEnvelope{Body: &body}
+	}
+
+	t.Run("identical", func(t *testing.T) {
+		diffs := diffSecrets(creds, creds)
+		if len(diffs) != 0 {
+			t.Errorf("diffSecrets() expected no differences, got %v", diffs)
+		}
+	})
+
+	t.Run("added, removed and changed", func(t *testing.T) {
+		b := []apitypes.CredentialEnvelope{
+			changed("foo", apitypes.NewStringCredentialValue("qux")),
+			changed("port", apitypes.NewIntCredentialValue(80)),
+		}
+
+		diffs := diffSecrets(creds, b)
+		expected := []struct {
+			name string
+			kind diffKind
+		}{
+			{"baz", diffRemoved},
+			{"foo", diffChanged},
+			{"port", diffAdded},
+		}
+
+		if len(diffs) != len(expected) {
+			t.Fatalf("diffSecrets() expected %d differences, got %d", len(expected), len(diffs))
+		}
+
+		for i, e := range expected {
+			if diffs[i].Name != e.name || diffs[i].Kind != e.kind {
+				t.Errorf("diffSecrets() expected %s (%d) at %d, got %s (%d)", e.name, e.kind, i,
+					diffs[i].Name, diffs[i].Kind)
+			}
+		}
+	})
+
+	t.Run("type changed", func(t *testing.T) {
+		a := []apitypes.CredentialEnvelope{changed("port", apitypes.NewStringCredentialValue("80"))}
+		b := []apitypes.CredentialEnvelope{changed("port", apitypes.NewIntCredentialValue(80))}
+
+		diffs := diffSecrets(a, b)
+		if len(diffs) != 1 || diffs[0].Kind != diffChanged {
+			t.Errorf("diffSecrets() expected port to have changed, got %v", diffs)
+		}
+	})
+}
+
+func TestParseServicePath(t *testing.T) {
+	newCtx := func(org, project string) *cli.Context {
+		flagset := flag.NewFlagSet("", flag.ContinueOnError)
+		flagset.String("org", org, "")
+		flagset.String("project", project, "")
+		flagset.String("service", "default", "")
+		return cli.NewContext(nil, flagset, nil)
+	}
+
+	tcs := []struct {
+		raw      string
+		org      string
+		project  string
+		expected string
+	}{
+		{"/o/p/e/s", "", "", "/o/p/e/s"},
+		{"/o/p/e/s", "org", "project", "/o/p/e/s"},
+		{"staging", "o", "p", "/o/p/staging/default"},
+		{"staging/api", "o", "p", "/o/p/staging/api"},
+		{"staging", "", "", ""},
+		{"/o/p/e", "", "", ""},
+		{"/o/p/e/*", "", "", ""},
+		{"a/b/c", "o", "p", ""},
+	}
+
+	for _, tc := range tcs {
+		p, err := parseServicePath(newCtx(tc.org, tc.project), tc.raw)
+		if tc.expected == "" {
+			if err == nil {
+				t.Errorf("parseServicePath(%q) expected an error, got %s", tc.raw, p)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseServicePath(%q) expected no errors, got %s", tc.raw, err)
+		} else if p.String() != tc.expected {
+			t.Errorf("parseServicePath(%q) expected %s, got %s", tc.raw, tc.expected, p)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -41,10 +42,11 @@ func init() {
 				Flags: []cli.Flag{
 					orgFlag("org to show environments for", false),
 					projectFlag("project to show environments for", false),
+					outputFlag,
 				},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					checkRequiredFlags, listEnvsCmd,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, checkRequiredFlags, listEnvsCmd,
 				),
 			},
 		},
@@ -100,6 +102,13 @@ func createEnv(ctx *cli.Context) error {
 
 const envListFailed = "Could not list envs, please try again."
 
+// envOutput is the structured output of an environment
+type envOutput struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Project string `json:"project"`
+}
+
 func listEnvsCmd(ctx *cli.Context) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -125,6 +134,14 @@ func listEnvsCmd(ctx *cli.Context) error {
 		return errs.NewErrorExitError(envListFailed, err)
 	}
 
+	if structuredOutput(ctx) {
+		out := make([]envOutput, len(envs))
+		for i, env := range envs {
+			out[i] = envOutput{ID: env.ID.String(), Name: env.Body.Name, Project: project.Body.Name}
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	// Build output of projects/envs
 	fmt.Println("")
 	fmt.Printf("%s\n", ui.BoldString("Environments"))
@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"unicode/utf16"
 
 	"github.com/urfave/cli"
 
@@ -14,7 +21,8 @@ import (
 	"github.com/manifoldco/torus-cli/errs"
 )
 
-var formatValues = []string{"env", "bash", "powershell", "fish", "cmd", "json", "tfvars"}
+var formatValues = []string{"env", "bash", "powershell", "fish", "cmd", "json", "yaml", "toml",
+	"properties", "docker", "tfvars", "k8s", "k8s-configmap"}
 var formatDescription = "Format of exported secrets (" + strings.Join(formatValues, ", ") + ")"
 
 type mod int
@@ -37,6 +45,12 @@ func init() {
 			stdEnvFlag,
 			serviceFlag("Use this service.", "default", true),
 			formatFlag(formatValues[0], formatDescription),
+			newPlaceholder("k8s-name", "NAME", "Name of the k8s resource (default: <project>-<environment>-<service>)",
+				"", "TORUS_K8S_NAME", false),
+			newPlaceholder("k8s-namespace", "NAMESPACE", "Namespace of the k8s resource",
+				"", "TORUS_K8S_NAMESPACE", false),
+			newSlicePlaceholder("k8s-label", "KEY=VALUE", "Label to apply to the k8s resource",
+				"", "", false),
 		},
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
@@ -63,6 +77,12 @@ func exportCmd(ctx *cli.Context) error {
 		return err
 	}
 
+	aliases, err := loadSecretAliases()
+	if err != nil {
+		return err
+	}
+	secrets = aliasSecrets(secrets, aliases)
+
 	format := ctx.String("format")
 	if !validFormat(format) {
 		return errs.NewUsageExitError(fmt.Sprintf("Invalid format provided: %s", format), ctx)
@@ -94,6 +114,27 @@ func exportCmd(ctx *cli.Context) error {
 		err = writeFormat(w, secrets, "%s = %s\n", quotes)
 	case "json":
 		err = writeJSONFormat(w, secrets)
+	case "yaml":
+		err = writeYAMLFormat(w, secrets)
+	case "toml":
+		err = writeTOMLFormat(w, secrets)
+	case "properties":
+		err = writePropertiesFormat(w, secrets)
+	case "docker":
+		err = writeDockerFormat(w, secrets)
+	case "k8s", "k8s-configmap":
+		var meta *k8sMetadata
+		meta, err = k8sMetadataFromFlags(ctx)
+		if err != nil {
+			return errs.NewUsageExitError(err.Error(), ctx)
+		}
+
+		kind := k8sSecretKind
+		if format == "k8s-configmap" {
+			kind = k8sConfigMapKind
+		}
+
+		err = writeK8sFormat(w, secrets, kind, meta)
 	default:
 		return errs.NewUsageExitError(fmt.Sprintf("Could not find format for %s", format), ctx)
 	}
@@ -151,6 +192,422 @@ func writeJSONFormat(w io.Writer, secrets []apitypes.CredentialEnvelope) error {
 	return nil
 }
 
+// sortedRawValues returns the names of the given secrets in sorted order, along
+// with a map of their typed values.
+func sortedRawValues(secrets []apitypes.CredentialEnvelope) ([]string, map[string]interface{}, error) {
+	names := make([]string, 0, len(secrets))
+	values := make(map[string]interface{})
+
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		v, err := (*secret.Body).GetValue().Raw()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = v
+	}
+	sort.Strings(names)
+
+	return names, values, nil
+}
+
+// sortedSecrets returns the secrets ordered by name. As with sortedRawValues,
+// only the last secret with a given name is kept.
+func sortedSecrets(secrets []apitypes.CredentialEnvelope) []apitypes.CredentialEnvelope {
+	names := make([]string, 0, len(secrets))
+	byName := make(map[string]apitypes.CredentialEnvelope)
+
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = secret
+	}
+	sort.Strings(names)
+
+	out := make([]apitypes.CredentialEnvelope, len(names))
+	for i, name := range names {
+		out[i] = byName[name]
+	}
+
+	return out
+}
+
+// formatFloat formats a float so it is always read back as a float, rather than
+// an integer, by YAML and TOML parsers. The given strings are used for
+// infinity and NaN values, which the two formats spell differently.
+func formatFloat(f float64, inf, nan string) string {
+	switch {
+	case math.IsNaN(f):
+		return nan
+	case math.IsInf(f, 1):
+		return inf
+	case math.IsInf(f, -1):
+		return "-" + inf
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+
+	return s
+}
+
+var (
+	yamlPlainKeyRe = regexp.MustCompile(`^[a-zA-Z_][-_a-zA-Z0-9]*$`)
+	tomlBareKeyRe  = regexp.MustCompile(`^[-_a-zA-Z0-9]+$`)
+)
+
+// yamlKey quotes a key if it would otherwise not be read back as a string,
+// such as `yes` or `null`.
+func yamlKey(name string) string {
+	switch strings.ToLower(name) {
+	case "y", "yes", "n", "no", "true", "false", "on", "off", "null":
+		return strconv.Quote(name)
+	}
+
+	if !yamlPlainKeyRe.MatchString(name) {
+		return strconv.Quote(name)
+	}
+
+	return name
+}
+
+// writeYAMLFormat writes the secrets as a YAML mapping. Strings are written as
+// double quoted scalars, which can safely hold any value, including multi-line
+// values such as PEM encoded keys.
+func writeYAMLFormat(w io.Writer, secrets []apitypes.CredentialEnvelope) error {
+	names, values, err := sortedRawValues(secrets)
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		_, err := fmt.Fprintln(w, "{}")
+		return err
+	}
+
+	for _, name := range names {
+		var value string
+		switch v := values[name].(type) {
+		case int:
+			value = strconv.Itoa(v)
+		case int64:
+			value = strconv.FormatInt(v, 10)
+		case float64:
+			value = formatFloat(v, ".inf", ".nan")
+		default:
+			// Go's quoted strings are valid YAML double quoted scalars.
+			value = strconv.Quote(fmt.Sprint(v))
+		}
+
+		_, err := fmt.Fprintf(w, "%s: %s\n", yamlKey(name), value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tomlQuote returns s as a TOML basic string. Unlike Go, TOML does not support
+// \x or \a style escapes, so other control characters are written as \u
+// escapes.
+func tomlQuote(s string) string {
+	var buf bytes.Buffer
+
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&buf, `\u%04X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+
+	return buf.String()
+}
+
+// writeTOMLFormat writes the secrets as top level TOML key/value pairs.
+func writeTOMLFormat(w io.Writer, secrets []apitypes.CredentialEnvelope) error {
+	names, values, err := sortedRawValues(secrets)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		key := name
+		if !tomlBareKeyRe.MatchString(key) {
+			key = tomlQuote(key)
+		}
+
+		var value string
+		switch v := values[name].(type) {
+		case int:
+			value = strconv.Itoa(v)
+		case int64:
+			value = strconv.FormatInt(v, 10)
+		case float64:
+			value = formatFloat(v, "inf", "nan")
+		default:
+			value = tomlQuote(fmt.Sprint(v))
+		}
+
+		_, err := fmt.Fprintf(w, "%s = %s\n", key, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// propertiesEscape escapes s for use in a Java .properties file, following the
+// same rules as java.util.Properties#store. Spaces are always escaped in keys,
+// but only when leading in values. Characters outside of printable ASCII are
+// written as \u escapes, as .properties files are read as ISO 8859-1.
+func propertiesEscape(s string, key bool) string {
+	var buf bytes.Buffer
+
+	for i, r := range s {
+		switch r {
+		case ' ':
+			if i == 0 || key {
+				buf.WriteByte('\\')
+			}
+			buf.WriteByte(' ')
+		case '\\', '=', ':', '#', '!':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\f':
+			buf.WriteString(`\f`)
+		default:
+			switch {
+			case r < 0x20 || r > 0x7e && r <= 0xffff:
+				fmt.Fprintf(&buf, `\u%04X`, r)
+			case r > 0xffff:
+				r1, r2 := utf16.EncodeRune(r)
+				fmt.Fprintf(&buf, `\u%04X\u%04X`, r1, r2)
+			default:
+				buf.WriteRune(r)
+			}
+		}
+	}
+
+	return buf.String()
+}
+
+// writePropertiesFormat writes the secrets as a Java .properties file.
+func writePropertiesFormat(w io.Writer, secrets []apitypes.CredentialEnvelope) error {
+	for _, secret := range sortedSecrets(secrets) {
+		name := propertiesEscape((*secret.Body).GetName(), true)
+		value := propertiesEscape((*secret.Body).GetValue().String(), false)
+
+		_, err := fmt.Fprintf(w, "%s=%s\n", name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeDockerFormat writes the secrets in the format expected by the
+// --env-file flag of `docker run`. Docker reads values verbatim, without any
+// quoting or escaping, so values spanning multiple lines can't be represented.
+//
+// Secrets are written in order of name, and names are upper cased, so two
+// secrets whose names differ only in case are an error.
+func writeDockerFormat(w io.Writer, secrets []apitypes.CredentialEnvelope) error {
+	seen := make(map[string]string)
+	for _, secret := range sortedSecrets(secrets) {
+		name := strings.ToUpper((*secret.Body).GetName())
+		value := (*secret.Body).GetValue().String()
+
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("The secrets %s and %s are both exported as %s", other,
+				(*secret.Body).GetName(), name)
+		}
+		seen[name] = (*secret.Body).GetName()
+
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("The value of %s contains a newline, which the docker format does not support", name)
+		}
+
+		_, err := fmt.Fprintf(w, "%s=%s\n", name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const (
+	k8sSecretKind    = "Secret"
+	k8sConfigMapKind = "ConfigMap"
+)
+
+var (
+	// k8s resource names must be valid DNS subdomains, and namespaces valid
+	// DNS labels, while data keys may only contain alphanumerics, '-', '_',
+	// or '.'.
+	k8sNameRe       = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]{0,251}[a-z0-9])?$`)
+	k8sNamespaceRe  = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+	k8sInvalidKeyRe = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+	k8sLabelRe      = regexp.MustCompile(`^(([a-z0-9]([-a-z0-9.]*[a-z0-9])?)/)?[a-zA-Z0-9]([-_.a-zA-Z0-9]{0,61}[a-zA-Z0-9])?$`)
+	k8sLabelValueRe = regexp.MustCompile(`^([a-zA-Z0-9]([-_.a-zA-Z0-9]{0,61}[a-zA-Z0-9])?)?$`)
+)
+
+// k8sMetadata holds the metadata section of an exported k8s resource.
+type k8sMetadata struct {
+	Name      string
+	Namespace string
+	Labels    map[string]string
+}
+
+// k8sMetadataFromFlags builds the metadata for a k8s resource from the
+// k8s-name, k8s-namespace and k8s-label flags. If no name is provided, one is
+// derived from the project, environment, and service.
+func k8sMetadataFromFlags(ctx *cli.Context) (*k8sMetadata, error) {
+	meta := &k8sMetadata{
+		Name:      ctx.String("k8s-name"),
+		Namespace: ctx.String("k8s-namespace"),
+		Labels:    make(map[string]string),
+	}
+
+	if meta.Name == "" {
+		name := strings.Join([]string{ctx.String("project"),
+			ctx.String("environment"), ctx.String("service")}, "-")
+		meta.Name = strings.Replace(name, "_", "-", -1)
+	}
+
+	if !k8sNameRe.MatchString(meta.Name) {
+		return nil, fmt.Errorf("Invalid k8s resource name: %s", meta.Name)
+	}
+
+	if meta.Namespace != "" && !k8sNamespaceRe.MatchString(meta.Namespace) {
+		return nil, fmt.Errorf("Invalid k8s namespace: %s", meta.Namespace)
+	}
+
+	for _, label := range ctx.StringSlice("k8s-label") {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) != 2 || !k8sLabelRe.MatchString(parts[0]) ||
+			!k8sLabelValueRe.MatchString(parts[1]) {
+			return nil, fmt.Errorf("Invalid k8s label: %s", label)
+		}
+
+		meta.Labels[parts[0]] = parts[1]
+	}
+
+	return meta, nil
+}
+
+// k8sKey sanitizes a secret name for use as a key in the data section of a k8s
+// Secret or ConfigMap. Names are upper cased, so they can be used directly as
+// environment variables via envFrom, and any characters k8s does not allow are
+// replaced with an underscore.
+func k8sKey(name string) string {
+	return k8sInvalidKeyRe.ReplaceAllString(strings.ToUpper(name), "_")
+}
+
+// writeK8sFormat writes the secrets as a k8s v1 Secret or ConfigMap manifest,
+// suitable for use with `kubectl apply -f -`.
+//
+// Secret values are base64 encoded, as required by k8s; ConfigMap values are
+// written as quoted strings.
+func writeK8sFormat(w io.Writer, secrets []apitypes.CredentialEnvelope, kind string, meta *k8sMetadata) error {
+	data := make(map[string]string)
+	keys := []string{}
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		key := k8sKey(name)
+		if _, ok := data[key]; ok {
+			return fmt.Errorf("Multiple secrets map to the k8s key %s", key)
+		}
+
+		data[key] = (*secret.Body).GetValue().String()
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintln(w, "apiVersion: v1")
+	fmt.Fprintf(w, "kind: %s\n", kind)
+	fmt.Fprintln(w, "metadata:")
+	fmt.Fprintf(w, "  name: %s\n", meta.Name)
+	if meta.Namespace != "" {
+		fmt.Fprintf(w, "  namespace: %s\n", meta.Namespace)
+	}
+
+	if len(meta.Labels) > 0 {
+		labels := make([]string, 0, len(meta.Labels))
+		for k := range meta.Labels {
+			labels = append(labels, k)
+		}
+		sort.Strings(labels)
+
+		fmt.Fprintln(w, "  labels:")
+		for _, k := range labels {
+			fmt.Fprintf(w, "    %s: %s\n", k, strconv.Quote(meta.Labels[k]))
+		}
+	}
+
+	if kind == k8sSecretKind {
+		fmt.Fprintln(w, "type: Opaque")
+	}
+
+	if len(keys) == 0 {
+		_, err := fmt.Fprintln(w, "data: {}")
+		return err
+	}
+
+	fmt.Fprintln(w, "data:")
+	for _, k := range keys {
+		value := data[k]
+		if kind == k8sSecretKind {
+			value = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+
+		// Go's quoted strings are valid YAML double quoted scalars.
+		_, err := fmt.Fprintf(w, "  %s: %s\n", k, strconv.Quote(value))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func validFormat(format string) bool {
 	for _, v := range formatValues {
 		if v == format {
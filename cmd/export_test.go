@@ -3,9 +3,43 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"flag"
+	"strings"
 	"testing"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
 )
 
+// typedCredentialsHelper returns credentials covering each value type, as well
+// as values which need escaping.
+func typedCredentialsHelper() []apitypes.CredentialEnvelope {
+	values := []struct {
+		name  string
+		value *apitypes.CredentialValue
+	}{
+		{"port", apitypes.NewIntCredentialValue(5432)},
+		{"ratio", apitypes.NewFloatCredentialValue(2)},
+		{"key", apitypes.NewStringCredentialValue("-----BEGIN KEY-----\nab\\c\n-----END KEY-----")},
+		{"greeting", apitypes.NewStringCredentialValue(" say \"hi\" = caf\u00e9")},
+	}
+
+	var creds []apitypes.CredentialEnvelope
+	for _, v := range values {
+		var cBody apitypes.Credential = &apitypes.CredentialV2{
+			State: "set",
+			BaseCredential: apitypes.BaseCredential{
+				Name:  v.name,
+				Value: v.value,
+			},
+		}
+		creds = append(creds, apitypes.CredentialEnvelope{Body: &cBody})
+	}
+
+	return creds
+}
+
 func TestWriteJSONFormat(t *testing.T) {
 	var buf bytes.Buffer
 	w := bufio.NewWriter(&buf)
@@ -30,3 +64,235 @@ func TestWriteJSONFormat(t *testing.T) {
 		t.Errorf("writeJSONFormat() expected\n%qgot\n%q", expected, got)
 	}
 }
+
+func TestWriteYAMLFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeYAMLFormat(&buf, typedCredentialsHelper())
+	if err != nil {
+		t.Fatalf("writeYAMLFormat() expected no errors, got %s", err)
+	}
+
+	expected := `greeting: " say \"hi\" = café"
+key: "-----BEGIN KEY-----\nab\\c\n-----END KEY-----"
+port: 5432
+ratio: 2.0
+`
+	if got := buf.String(); expected != got {
+		t.Errorf("writeYAMLFormat() expected\n%qgot\n%q", expected, got)
+	}
+}
+
+func TestYAMLKey(t *testing.T) {
+	tcs := map[string]string{
+		"foo":     "foo",
+		"foo-bar": "foo-bar",
+		"yes":     `"yes"`,
+		"Null":    `"Null"`,
+		"1foo":    `"1foo"`,
+	}
+
+	for in, expected := range tcs {
+		if got := yamlKey(in); got != expected {
+			t.Errorf("yamlKey(%q) expected %q, got %q", in, expected, got)
+		}
+	}
+}
+
+func TestWriteTOMLFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeTOMLFormat(&buf, typedCredentialsHelper())
+	if err != nil {
+		t.Fatalf("writeTOMLFormat() expected no errors, got %s", err)
+	}
+
+	expected := `greeting = " say \"hi\" = café"
+key = "-----BEGIN KEY-----\nab\\c\n-----END KEY-----"
+port = 5432
+ratio = 2.0
+`
+	if got := buf.String(); expected != got {
+		t.Errorf("writeTOMLFormat() expected\n%qgot\n%q", expected, got)
+	}
+}
+
+func TestTOMLQuote(t *testing.T) {
+	tcs := map[string]string{
+		"foo":       `"foo"`,
+		"a\x00b":    `"a\u0000b"`,
+		"bell\a":    `"bell\u0007"`,
+		"tab\there": `"tab\there"`,
+	}
+
+	for in, expected := range tcs {
+		if got := tomlQuote(in); got != expected {
+			t.Errorf("tomlQuote(%q) expected %q, got %q", in, expected, got)
+		}
+	}
+}
+
+func TestWritePropertiesFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := writePropertiesFormat(&buf, typedCredentialsHelper())
+	if err != nil {
+		t.Fatalf("writePropertiesFormat() expected no errors, got %s", err)
+	}
+
+	expected := `greeting=\ say "hi" \= caf\u00E9
+key=-----BEGIN KEY-----\nab\\c\n-----END KEY-----
+port=5432
+ratio=2
+`
+	if got := buf.String(); expected != got {
+		t.Errorf("writePropertiesFormat() expected\n%qgot\n%q", expected, got)
+	}
+}
+
+func TestWriteDockerFormat(t *testing.T) {
+	t.Run("single line values", func(t *testing.T) {
+		var buf bytes.Buffer
+		creds, _ := viewCredentialsHelper(t)
+		err := writeDockerFormat(&buf, creds)
+		if err != nil {
+			t.Fatalf("writeDockerFormat() expected no errors, got %s", err)
+		}
+
+		expected := "BAZ=two words\nFOO=bar\n"
+		if got := buf.String(); expected != got {
+			t.Errorf("writeDockerFormat() expected\n%qgot\n%q", expected, got)
+		}
+	})
+
+	t.Run("multi-line values", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeDockerFormat(&buf, typedCredentialsHelper())
+		if err == nil {
+			t.Error("writeDockerFormat() expected an error for a multi-line value")
+		}
+	})
+
+	t.Run("names differing in case", func(t *testing.T) {
+		var creds []apitypes.CredentialEnvelope
+		for _, name := range []string{"foo", "FOO"} {
+			var cBody apitypes.Credential = &apitypes.CredentialV2{
+				State: "set",
+				BaseCredential: apitypes.BaseCredential{
+					Name:  name,
+					Value: apitypes.NewStringCredentialValue("bar"),
+				},
+			}
+			creds = append(creds, apitypes.CredentialEnvelope{Body: &cBody})
+		}
+
+		var buf bytes.Buffer
+		if err := writeDockerFormat(&buf, creds); err == nil {
+			t.Error("writeDockerFormat() expected an error for names differing in case")
+		}
+	})
+}
+
+func TestWriteK8sFormat(t *testing.T) {
+	creds, _ := viewCredentialsHelper(t)
+	meta := &k8sMetadata{
+		Name:      "api-production-default",
+		Namespace: "api",
+		Labels:    map[string]string{"app": "api", "tier": "backend"},
+	}
+
+	t.Run("secret", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeK8sFormat(&buf, creds, k8sSecretKind, meta)
+		if err != nil {
+			t.Fatalf("writeK8sFormat() expected no errors, got %s", err)
+		}
+
+		expected := `apiVersion: v1
+kind: Secret
+metadata:
+  name: api-production-default
+  namespace: api
+  labels:
+    app: "api"
+    tier: "backend"
+type: Opaque
+data:
+  BAZ: "dHdvIHdvcmRz"
+  FOO: "YmFy"
+`
+		if got := buf.String(); expected != got {
+			t.Errorf("writeK8sFormat() expected\n%qgot\n%q", expected, got)
+		}
+	})
+
+	t.Run("configmap", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeK8sFormat(&buf, creds, k8sConfigMapKind, &k8sMetadata{Name: "cfg"})
+		if err != nil {
+			t.Fatalf("writeK8sFormat() expected no errors, got %s", err)
+		}
+
+		expected := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cfg
+data:
+  BAZ: "two words"
+  FOO: "bar"
+`
+		if got := buf.String(); expected != got {
+			t.Errorf("writeK8sFormat() expected\n%qgot\n%q", expected, got)
+		}
+	})
+}
+
+func TestK8sKey(t *testing.T) {
+	tcs := map[string]string{
+		"foo":         "FOO",
+		"foo-bar_baz": "FOO-BAR_BAZ",
+		"foo bar":     "FOO_BAR",
+		"foo:bar/baz": "FOO_BAR_BAZ",
+	}
+
+	for in, expected := range tcs {
+		if got := k8sKey(in); got != expected {
+			t.Errorf("k8sKey(%q) expected %q, got %q", in, expected, got)
+		}
+	}
+}
+
+func TestK8sMetadataFromFlags(t *testing.T) {
+	tcs := []struct {
+		namespace string
+		err       bool
+	}{
+		{namespace: ""},
+		{namespace: "api"},
+		{namespace: "api-prod-1"},
+		{namespace: strings.Repeat("a", 63)},
+		{namespace: strings.Repeat("a", 64), err: true},
+		{namespace: "a.b", err: true},
+		{namespace: "API", err: true},
+		{namespace: "-api", err: true},
+	}
+
+	for _, tc := range tcs {
+		flagset := flag.NewFlagSet("", flag.ContinueOnError)
+		flagset.String("k8s-name", "api", "")
+		flagset.String("k8s-namespace", tc.namespace, "")
+		flagset.Var(&cli.StringSlice{}, "k8s-label", "")
+		ctx := cli.NewContext(nil, flagset, nil)
+
+		meta, err := k8sMetadataFromFlags(ctx)
+		if tc.err {
+			if err == nil {
+				t.Errorf("k8sMetadataFromFlags() expected an error for namespace %q", tc.namespace)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("k8sMetadataFromFlags() expected no error for namespace %q, got %s", tc.namespace, err)
+		} else if meta.Namespace != tc.namespace {
+			t.Errorf("k8sMetadataFromFlags() expected namespace %q, got %q", tc.namespace, meta.Namespace)
+		}
+	}
+}
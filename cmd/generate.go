@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/hints"
+)
+
+// alphabets are the named sets of characters passwords can be generated from.
+var alphabets = map[string]string{
+	"alnum":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"alpha":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"numeric": "0123456789",
+	"symbols": "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// publicKeySuffix is appended to the name of a generated keypair's private
+// key to name the secret holding its public key.
+const publicKeySuffix = "_public"
+
+func init() {
+	generate := cli.Command{
+		Name:      "generate",
+		Usage:     "Set a secret to a randomly generated value",
+		ArgsUsage: "<name|path>",
+		Category:  "SECRETS",
+		Flags: append(setUnsetFlags,
+			newPlaceholder("kind, k", "KIND", "Kind of value, one of password, hex, base64, uuid, ed25519 or rsa",
+				"password", "", false),
+			newPlaceholder("length, l", "LENGTH", "Number of characters in a password, or bytes for hex and base64",
+				"32", "", false),
+			newPlaceholder("alphabet", "ALPHABET", "Characters to use in a password, either alnum, alpha, "+
+				"numeric, symbols, or the characters themselves", "alnum", "", false),
+			newPlaceholder("bits", "BITS", "Size of an RSA key, one of 2048, 3072 or 4096", "4096", "", false),
+			cli.BoolFlag{
+				Name:  "show",
+				Usage: "Display the generated value",
+			},
+		),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setSliceDefaults, generateCmd,
+		),
+	}
+
+	Cmds = append(Cmds, generate)
+}
+
+func generateCmd(ctx *cli.Context) error {
+	if err := argCheck(ctx, 1, 1); err != nil {
+		return err
+	}
+
+	length, err := strconv.Atoi(ctx.String("length"))
+	if err != nil {
+		return errs.NewUsageExitError("--length must be a number", ctx)
+	}
+
+	bits, err := strconv.Atoi(ctx.String("bits"))
+	if err != nil {
+		return errs.NewUsageExitError("--bits must be a number", ctx)
+	}
+
+	path, cname, err := determinePath(ctx, ctx.Args()[0])
+	if err != nil {
+		return err
+	}
+	name := strings.ToLower(*cname)
+
+	s, _ := spinner("Generating value")
+	s.Start()
+	values, err := generateValues(name, ctx.String("kind"), length, ctx.String("alphabet"), bits)
+	s.Stop()
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	makers := valueMakers{}
+	for name, value := range values {
+		makers[name] = func(value string) valueMaker {
+			return func() *apitypes.CredentialValue {
+				return apitypes.NewStringCredentialValue(value)
+			}
+		}(value)
+	}
+
+	s, p := spinner("Attempting to set credentials")
+	s.Start()
+	_, err = setCredentials(ctx, path, makers, p)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Could not set credentials.", err)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println()
+	for _, name := range names {
+		fmt.Printf("Credential %s has been set at %s/%s\n", name, displayPathExp(path), name)
+	}
+
+	if ctx.Bool("show") {
+		for _, name := range names {
+			fmt.Printf("\n%s:\n%s\n", name, values[name])
+		}
+	}
+
+	hints.Display(hints.View, hints.Run)
+	return nil
+}
+
+// generateValues returns the randomly generated secrets of the given kind,
+// keyed by their name. Keypairs are stored as two secrets, with the public
+// key's name suffixed by publicKeySuffix.
+//
+// length is the number of characters for passwords, and the number of random
+// bytes for hex and base64 values.
+func generateValues(name, kind string, length int, alphabet string, bits int) (map[string]string, error) {
+	switch kind {
+	case "password", "hex", "base64":
+		if length < 1 || length > maxSecretSize/2 {
+			return nil, fmt.Errorf("Length must be between 1 and %d", maxSecretSize/2)
+		}
+	}
+
+	var value string
+	var err error
+	switch kind {
+	case "password":
+		value, err = generatePassword(length, alphabet)
+	case "hex":
+		var b []byte
+		b, err = randomBytes(length)
+		value = hex.EncodeToString(b)
+	case "base64":
+		var b []byte
+		b, err = randomBytes(length)
+		value = base64.StdEncoding.EncodeToString(b)
+	case "uuid":
+		value, err = generateUUID()
+	case "ed25519":
+		return generateKeypair(name, generateEd25519Keypair)
+	case "rsa":
+		return generateKeypair(name, func() (string, string, error) {
+			return generateRSAKeypair(bits)
+		})
+	default:
+		return nil, fmt.Errorf("Unknown kind %q, expected one of password, hex, base64, uuid, ed25519 or rsa", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{name: value}, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(rand.Reader, b)
+	return b, err
+}
+
+// generatePassword returns a password of the given length, with each character
+// chosen uniformly from the alphabet, which is either a named alphabet, or
+// the characters to use.
+func generatePassword(length int, alphabet string) (string, error) {
+	chars, ok := alphabets[alphabet]
+	if !ok {
+		chars = alphabet
+	}
+
+	runes := []rune{}
+	seen := make(map[rune]bool)
+	for _, r := range chars {
+		if !seen[r] {
+			seen[r] = true
+			runes = append(runes, r)
+		}
+	}
+	if len(runes) < 2 {
+		return "", errors.New("An alphabet must contain at least two different characters")
+	}
+
+	max := big.NewInt(int64(len(runes)))
+	password := make([]rune, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		password[i] = runes[n.Int64()]
+	}
+
+	return string(password), nil
+}
+
+// generateUUID returns a random (version 4) UUID.
+func generateUUID() (string, error) {
+	b, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+func generateKeypair(name string, gen func() (string, string, error)) (map[string]string, error) {
+	private, public, err := gen()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		name:                   private,
+		name + publicKeySuffix: public,
+	}, nil
+}
+
+// The ASN.1 DER prefixes of Ed25519 PKCS #8 private keys and PKIX public keys,
+// as defined in RFC 8410. Each is followed by the 32 byte seed or public key.
+var (
+	ed25519PKCS8Prefix = []byte{0x30, 0x2e, 0x02, 0x01, 0x00, 0x30, 0x05, 0x06, 0x03, 0x2b, 0x65, 0x70,
+		0x04, 0x22, 0x04, 0x20}
+	ed25519PKIXPrefix = []byte{0x30, 0x2a, 0x30, 0x05, 0x06, 0x03, 0x2b, 0x65, 0x70, 0x03, 0x21, 0x00}
+)
+
+// generateEd25519Keypair returns a new Ed25519 private and public key, PEM
+// encoded.
+func generateEd25519Keypair() (string, string, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	seed := priv[:32]
+	private := pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: append(append([]byte{}, ed25519PKCS8Prefix...), seed...),
+	})
+	public := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: append(append([]byte{}, ed25519PKIXPrefix...), pub...),
+	})
+
+	return string(private), string(public), nil
+}
+
+// generateRSAKeypair returns a new RSA private and public key of the given
+// size, PEM encoded.
+func generateRSAKeypair(bits int) (string, string, error) {
+	switch bits {
+	case 2048, 3072, 4096:
+	default:
+		return "", "", errors.New("Bits must be one of 2048, 3072 or 4096")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return "", "", err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	pubDer, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	private := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	public := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer})
+
+	return string(private), string(public), nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	password, err := generatePassword(64, "numeric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^[0-9]{64}$`).MatchString(password) {
+		t.Errorf("generatePassword() expected 64 digits, got %q", password)
+	}
+
+	password, err = generatePassword(16, "ab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Trim(password, "ab") != "" || len(password) != 16 {
+		t.Errorf("generatePassword() expected 16 of a and b, got %q", password)
+	}
+
+	if _, err := generatePassword(16, "aaa"); err == nil {
+		t.Error("generatePassword() expected an error for a single character alphabet")
+	}
+}
+
+func TestGenerateValues(t *testing.T) {
+	t.Run("hex", func(t *testing.T) {
+		values, err := generateValues("key", "hex", 16, "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, err := hex.DecodeString(values["key"]); err != nil || len(b) != 16 {
+			t.Errorf("generateValues() expected 16 hex encoded bytes, got %q", values["key"])
+		}
+	})
+
+	t.Run("uuid", func(t *testing.T) {
+		values, err := generateValues("id", "uuid", 0, "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+		if !re.MatchString(values["id"]) {
+			t.Errorf("generateValues() expected a v4 uuid, got %q", values["id"])
+		}
+	})
+
+	t.Run("ed25519", func(t *testing.T) {
+		values, err := generateValues("signing", "ed25519", 0, "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		priv, _ := pem.Decode([]byte(values["signing"]))
+		pub, _ := pem.Decode([]byte(values["signing_public"]))
+		if priv == nil || pub == nil {
+			t.Fatalf("generateValues() expected PEM encoded keys, got %v", values)
+		}
+
+		if !bytes.HasPrefix(priv.Bytes, ed25519PKCS8Prefix) || !bytes.HasPrefix(pub.Bytes, ed25519PKIXPrefix) {
+			t.Fatal("generateValues() expected PKCS #8 and PKIX encoded keys")
+		}
+
+		seed := priv.Bytes[len(ed25519PKCS8Prefix):]
+		_, key, err := ed25519.GenerateKey(bytes.NewReader(seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sig := ed25519.Sign(key, []byte("message"))
+		if !ed25519.Verify(pub.Bytes[len(ed25519PKIXPrefix):], []byte("message"), sig) {
+			t.Error("generateValues() expected the public key to match the private key")
+		}
+	})
+
+	t.Run("rsa", func(t *testing.T) {
+		values, err := generateValues("tls", "rsa", 0, "", 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		block, _ := pem.Decode([]byte(values["tls"]))
+		if block == nil {
+			t.Fatalf("generateValues() expected a PEM encoded key, got %q", values["tls"])
+		}
+
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k, ok := key.(*rsa.PrivateKey); !ok || k.N.BitLen() != 2048 {
+			t.Errorf("generateValues() expected a 2048 bit RSA key, got %T", key)
+		}
+
+		if _, ok := values["tls_public"]; !ok {
+			t.Error("generateValues() expected a public key")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := generateValues("x", "password", 0, "alnum", 0); err == nil {
+			t.Error("generateValues() expected an error for a zero length")
+		}
+		if _, err := generateValues("x", "rsa", 0, "", 1024); err == nil {
+			t.Error("generateValues() expected an error for a 1024 bit RSA key")
+		}
+		if _, err := generateValues("x", "dsa", 0, "", 0); err == nil {
+			t.Error("generateValues() expected an error for an unknown kind")
+		}
+	})
+}
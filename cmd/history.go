@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/juju/ansiterm"
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func init() {
+	history := cli.Command{
+		Name:      "history",
+		Usage:     "List every version of a secret, and who set it",
+		ArgsUsage: "<name|path>",
+		Category:  "SECRETS",
+		Flags: append(setUnsetFlags, cli.BoolFlag{
+			Name:  "show-values",
+			Usage: "Decrypt and display the value of each version",
+		}),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setSliceDefaults, historyCmd,
+		),
+	}
+
+	Cmds = append(Cmds, history)
+}
+
+func historyCmd(ctx *cli.Context) error {
+	if err := argCheck(ctx, 1, 1); err != nil {
+		return err
+	}
+
+	pe, name, err := determinePath(ctx, ctx.Args()[0])
+	if err != nil {
+		return errs.NewErrorExitError("Could not retrieve history", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	showValues := ctx.Bool("show-values")
+
+	s, p := spinner("Retrieving history")
+	s.Start()
+	history, err := client.Credentials.History(c, pe.String(), *name, !showValues, p)
+	s.Stop()
+	if err != nil {
+		if apitypes.IsNotFoundError(err) {
+			return errs.NewExitError(fmt.Sprintf("Secret %s/%s not found.", displayPathExp(pe), *name))
+		}
+		return errs.NewErrorExitError("Could not retrieve history", err)
+	}
+
+	setBy := historyNames(c, client, history)
+
+	fmt.Printf("History of %s/%s\n\n", displayPathExp(pe), *name)
+	writeHistory(os.Stdout, history, setBy, showValues)
+
+	return nil
+}
+
+// historyNames returns the username of each user, and the name of each
+// machine, that set a version in the given history. Identities that can't be
+// looked up are left out, and displayed by ID.
+func historyNames(c context.Context, client *api.Client, history []apitypes.CredentialHistoryItem) map[identity.ID]string {
+	names := make(map[identity.ID]string)
+
+	userType := (&primitive.User{}).Type()
+	machineType := (&primitive.Machine{}).Type()
+
+	var userIDs []identity.ID
+	var machineIDs []identity.ID
+	seen := make(map[identity.ID]bool)
+	for _, h := range history {
+		if h.SetBy == nil || seen[*h.SetBy] {
+			continue
+		}
+		seen[*h.SetBy] = true
+
+		switch h.SetBy.Type() {
+		case userType:
+			userIDs = append(userIDs, *h.SetBy)
+		case machineType:
+			machineIDs = append(machineIDs, *h.SetBy)
+		}
+	}
+
+	if len(userIDs) > 0 {
+		profiles, err := client.Profiles.ListByID(c, userIDs)
+		if err == nil {
+			for _, p := range profiles {
+				names[*p.ID] = p.Body.Username
+			}
+		}
+	}
+
+	for _, id := range machineIDs {
+		id := id
+		segment, err := client.Machines.Get(c, &id)
+		if err == nil && segment != nil {
+			names[id] = segment.Machine.Body.Name
+		}
+	}
+
+	return names
+}
+
+// writeHistory writes a table of the given history, one version per line.
+// Values are only displayed when showValues is true; otherwise the state of
+// each version is shown.
+func writeHistory(w io.Writer, history []apitypes.CredentialHistoryItem, names map[identity.ID]string, showValues bool) {
+	tw := ansiterm.NewTabWriter(w, 2, 0, 2, ' ', 0)
+
+	last := "STATE"
+	if showValues {
+		last = "VALUE"
+	}
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", ui.BoldString("VERSION"), ui.BoldString("KEYRING"),
+		ui.BoldString("KEYRING CREATED"), ui.BoldString("SET BY"), ui.BoldString(last))
+
+	for _, h := range history {
+		setBy := "-"
+		if h.SetBy != nil {
+			setBy = names[*h.SetBy]
+			if setBy == "" {
+				setBy = h.SetBy.String()
+			}
+		}
+
+		created := "-"
+		if !h.KeyringCreated.IsZero() {
+			created = h.KeyringCreated.Format(time.RFC3339)
+		}
+
+		var value string
+		cValue := (*h.Credential.Body).GetValue()
+		switch {
+		case cValue == nil:
+			value = ui.FaintString("unset")
+		case showValues:
+			value = diffValue(cValue)
+		default:
+			value = "set"
+		}
+
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\n", h.CredentialVersion, h.KeyringVersion, created, setBy, value)
+	}
+
+	tw.Flush()
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/prefs"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func TestWriteHistory(t *testing.T) {
+	ui.Init(&prefs.Preferences{})
+	creds, _ := viewCredentialsHelper(t)
+
+	var unset apitypes.Credential = &apitypes.CredentialV2{
+		State:          "unset",
+		BaseCredential: apitypes.BaseCredential{Name: "foo", Value: apitypes.NewUnsetCredentialValue()},
+	}
+
+	user, err := identity.DecodeFromString("04100000000000000000000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Date(2017, 6, 2, 14, 11, 52, 0, time.UTC)
+	history := []apitypes.CredentialHistoryItem{
+		{Credential: creds[0], CredentialVersion: 3, KeyringVersion: 2, KeyringCreated: created, SetBy: &user},
+		{Credential: apitypes.CredentialEnvelope{Body: &unset}, CredentialVersion: 2, KeyringVersion: 1},
+		{Credential: creds[1], CredentialVersion: 1, KeyringVersion: 1},
+	}
+	names := map[identity.ID]string{user: "jeff"}
+
+	t.Run("without values", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		writeHistory(buf, history, names, false)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("writeHistory() expected 4 lines, got %d: %q", len(lines), buf.String())
+		}
+
+		expected := [][]string{
+			{"3", "2", "2017-06-02T14:11:52Z", "jeff", "set"},
+			{"2", "1", "-", "-", "unset"},
+			{"1", "1", "-", "-", "set"},
+		}
+		for i, e := range expected {
+			fields := strings.Fields(lines[i+1])
+			if strings.Join(fields, " ") != strings.Join(e, " ") {
+				t.Errorf("writeHistory() expected line %d to be %v, got %v", i+1, e, fields)
+			}
+		}
+	})
+
+	t.Run("with values", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		writeHistory(buf, history, names, true)
+
+		out := buf.String()
+		if !strings.Contains(out, "bar") || !strings.Contains(out, `"two words"`) {
+			t.Errorf("writeHistory() expected values to be displayed, got %q", out)
+		}
+	})
+}
@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/dirprefs"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+// hookStateVar holds the state of the secrets loaded into the shell by the
+// hook, so they can be unset when leaving the linked directory.
+const hookStateVar = "TORUS_HOOK_STATE"
+
+var hookShells = []string{"bash", "zsh", "fish"}
+
+// hookScripts are the prompt hooks for each shell. They are given the quoted
+// path of the torus executable.
+var hookScripts = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(`_torus_hook() {
+  local previous_exit_status=$?
+  eval "$({{.}} env-diff --shell bash)"
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND:-};" != *";_torus_hook;"* ]]; then
+  PROMPT_COMMAND="_torus_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`)),
+	"zsh": template.Must(template.New("zsh").Parse(`_torus_hook() {
+  eval "$({{.}} env-diff --shell zsh)"
+}
+typeset -ag precmd_functions
+if [[ -z "${precmd_functions[(r)_torus_hook]+1}" ]]; then
+  precmd_functions=( _torus_hook ${precmd_functions[@]} )
+fi
+typeset -ag chpwd_functions
+if [[ -z "${chpwd_functions[(r)_torus_hook]+1}" ]]; then
+  chpwd_functions=( _torus_hook ${chpwd_functions[@]} )
+fi
+`)),
+	"fish": template.Must(template.New("fish").Parse(`function __torus_hook --on-event fish_prompt
+  {{.}} env-diff --shell fish | source
+end
+`)),
+}
+
+// envVarName matches the names that can be exported by every supported shell.
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func init() {
+	hook := cli.Command{
+		Name:      "hook",
+		Usage:     "Output a shell hook which loads secrets when entering a linked directory",
+		ArgsUsage: "<" + strings.Join(hookShells, "|") + ">",
+		Category:  "SECRETS",
+		Action:    chain(hookCmd),
+	}
+
+	envDiff := cli.Command{
+		Name:     "env-diff",
+		Usage:    "Output the shell statements which update the secrets loaded by the shell hook",
+		Category: "SECRETS",
+		Flags: []cli.Flag{
+			stdOrgFlag,
+			stdProjectFlag,
+			stdEnvFlag,
+			serviceFlag("Use this service.", "default", true),
+			newPlaceholder("shell", "SHELL", "Output statements for this shell ("+strings.Join(hookShells, ", ")+")",
+				"", "", true),
+			newPlaceholder("ttl", "DURATION", "How long loaded secrets are used before being fetched again",
+				"5m", "TORUS_HOOK_TTL", false),
+		},
+		Action: chain(envDiffCmd),
+	}
+
+	Cmds = append(Cmds, hook, envDiff)
+}
+
+func hookCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		return errs.NewUsageExitError("A shell is required", ctx)
+	}
+
+	tmpl, ok := hookScripts[args[0]]
+	if !ok {
+		return errs.NewUsageExitError("Unsupported shell: "+args[0], ctx)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		exe = ctx.App.Name
+	}
+
+	return tmpl.Execute(os.Stdout, shellQuote(args[0], exe))
+}
+
+// hookState records the secrets loaded into the shell, and where they were
+// loaded from.
+type hookState struct {
+	Link     string    `json:"link"`
+	ModTime  time.Time `json:"mod_time"`
+	PathExp  string    `json:"path"`
+	LoadedAt time.Time `json:"loaded_at"`
+	Names    []string  `json:"names"`
+
+	// Previous holds the values variables had before they were overwritten
+	// by a loaded secret, so they can be restored. Variables which weren't
+	// set before aren't included.
+	Previous map[string]string `json:"previous,omitempty"`
+}
+
+func envDiffCmd(ctx *cli.Context) error {
+	shell := ctx.String("shell")
+	if _, ok := hookScripts[shell]; !ok {
+		return errs.NewUsageExitError("Unsupported shell: "+shell, ctx)
+	}
+
+	ttl, err := time.ParseDuration(ctx.String("ttl"))
+	if err != nil || ttl < 0 {
+		return errs.NewUsageExitError("Invalid ttl: "+ctx.String("ttl"), ctx)
+	}
+
+	prev := decodeHookState(os.Getenv(hookStateVar))
+
+	d, err := dirprefs.Load(true)
+	if err != nil {
+		return err
+	}
+
+	// Leaving a linked directory is handled without talking to the daemon,
+	// so the hook stays fast outside of linked directories.
+	if d.Path == "" {
+		return unloadHookSecrets(shell, prev)
+	}
+
+	info, err := os.Stat(d.Path)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureDaemon(ctx); err != nil {
+		return err
+	}
+
+	// A logged out shell unloads its secrets without an error, rather than
+	// printing one at every prompt.
+	if err := ensureSession(ctx); err != nil {
+		return unloadHookSecrets(shell, prev)
+	}
+
+	for _, f := range []actionFunc{loadDirPrefs, loadPrefDefaults, setUserEnv, checkRequiredFlags} {
+		if err := f(ctx); err != nil {
+			return err
+		}
+	}
+
+	state := &hookState{
+		Link:    d.Path,
+		ModTime: info.ModTime(),
+		PathExp: fmt.Sprintf("/%s/%s/%s/%s", ctx.String("org"), ctx.String("project"),
+			ctx.String("environment"), ctx.String("service")),
+	}
+
+	if prev != nil && prev.Link == state.Link && prev.ModTime.Equal(state.ModTime) &&
+		prev.PathExp == state.PathExp && time.Since(prev.LoadedAt) < ttl {
+		return nil
+	}
+
+	secrets, _, err := fetchSecrets(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	aliases, err := loadSecretAliases()
+	if err != nil {
+		return err
+	}
+
+	values := hookValues(aliasSecrets(secrets, aliases))
+	for name := range values {
+		state.Names = append(state.Names, name)
+	}
+	sort.Strings(state.Names)
+	state.LoadedAt = time.Now()
+
+	return writeEnvDiff(os.Stdout, shell, envDiff(prev, state, values, os.LookupEnv), state)
+}
+
+// unloadHookSecrets writes the shell statements which unload the secrets in
+// prev, if any were loaded.
+func unloadHookSecrets(shell string, prev *hookState) error {
+	if prev == nil {
+		return nil
+	}
+
+	return writeEnvDiff(os.Stdout, shell, envDiff(prev, nil, nil, os.LookupEnv), nil)
+}
+
+// hookValues returns the values of the secrets, keyed by the name of their
+// environment variable. Secrets which can't be used as environment variables
+// are skipped.
+func hookValues(secrets []apitypes.CredentialEnvelope) map[string]string {
+	values := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		name := strings.ToUpper((*secret.Body).GetName())
+		if !envVarName.MatchString(name) {
+			ui.Warn("Skipping secret %s, which is not a valid environment variable name", name)
+			continue
+		}
+
+		values[name] = (*secret.Body).GetValue().String()
+	}
+
+	return values
+}
+
+// envDiff returns the changes to make to the environment of a shell which
+// has loaded the secrets in prev, so it has the given values. Variables with
+// a nil value in the result are unset.
+//
+// Like direnv, a variable which is no longer loaded is restored to the value
+// it had before it was first loaded. Those values are recorded in state,
+// which may be nil when unloading, using lookup to find the current value of
+// newly loaded variables.
+func envDiff(prev, state *hookState, values map[string]string,
+	lookup func(string) (string, bool)) map[string]*string {
+
+	loaded := make(map[string]bool)
+	previous := make(map[string]string)
+	diff := make(map[string]*string)
+	if prev != nil {
+		for _, name := range prev.Names {
+			loaded[name] = true
+			diff[name] = nil
+			if v, ok := prev.Previous[name]; ok {
+				previous[name] = v
+				diff[name] = &v
+			}
+		}
+	}
+
+	if state != nil {
+		state.Previous = nil
+	}
+
+	for name, value := range values {
+		v := value
+		diff[name] = &v
+
+		if state == nil {
+			continue
+		}
+
+		p, ok := previous[name]
+		if !loaded[name] {
+			p, ok = lookup(name)
+		}
+		if ok {
+			if state.Previous == nil {
+				state.Previous = make(map[string]string)
+			}
+			state.Previous[name] = p
+		}
+	}
+
+	return diff
+}
+
+// writeEnvDiff writes the shell statements for the diff, in order of name,
+// followed by those saving the new state. If state is nil, the saved state is
+// removed.
+func writeEnvDiff(w io.Writer, shell string, diff map[string]*string, state *hookState) error {
+	names := make([]string, 0, len(diff))
+	for name := range diff {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if diff[name] == nil {
+			fmt.Fprintln(w, unsetStatement(shell, name))
+		} else {
+			fmt.Fprintln(w, exportStatement(shell, name, *diff[name]))
+		}
+	}
+
+	if state == nil {
+		_, err := fmt.Fprintln(w, unsetStatement(shell, hookStateVar))
+		return err
+	}
+
+	encoded, err := encodeHookState(state)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, exportStatement(shell, hookStateVar, encoded))
+	return err
+}
+
+func exportStatement(shell, name, value string) string {
+	if shell == "fish" {
+		return fmt.Sprintf("set -gx %s %s;", name, shellQuote(shell, value))
+	}
+
+	return fmt.Sprintf("export %s=%s;", name, shellQuote(shell, value))
+}
+
+func unsetStatement(shell, name string) string {
+	if shell == "fish" {
+		return fmt.Sprintf("set -e %s;", name)
+	}
+
+	return fmt.Sprintf("unset %s;", name)
+}
+
+// shellQuote quotes the value so it is used literally by the given shell.
+func shellQuote(shell, value string) string {
+	if shell == "fish" {
+		r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+		return "'" + r.Replace(value) + "'"
+	}
+
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+func encodeHookState(state *hookState) (string, error) {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeHookState returns the saved hook state, or nil if there is none. An
+// invalid state is treated as missing.
+func decodeHookState(encoded string) *hookState {
+	if encoded == "" {
+		return nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil
+	}
+
+	state := &hookState{}
+	if err := json.Unmarshal(raw, state); err != nil {
+		return nil
+	}
+
+	return state
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShellQuote(t *testing.T) {
+	tcs := []struct {
+		shell string
+		value string
+		want  string
+	}{
+		{"bash", "plain", `'plain'`},
+		{"bash", `it's $HOME`, `'it'\''s $HOME'`},
+		{"zsh", `a\b`, `'a\b'`},
+		{"fish", `it's a\b`, `'it\'s a\\b'`},
+	}
+
+	for _, tc := range tcs {
+		if got := shellQuote(tc.shell, tc.value); got != tc.want {
+			t.Errorf("shellQuote(%s, %q) expected %s, got %s", tc.shell, tc.value, tc.want, got)
+		}
+	}
+}
+
+func noEnv(string) (string, bool) { return "", false }
+
+func TestWriteEnvDiff(t *testing.T) {
+	prev := &hookState{Names: []string{"OLD", "PORT"}}
+	values := map[string]string{"PORT": "80", "TOKEN": "a'b"}
+
+	t.Run("loading", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		state := &hookState{Link: "/src/.torus.json", Names: []string{"PORT", "TOKEN"}}
+		if err := writeEnvDiff(buf, "bash", envDiff(prev, state, values, noEnv), state); err != nil {
+			t.Fatalf("writeEnvDiff() expected no errors, got %s", err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		want := []string{"unset OLD;", "export PORT='80';", `export TOKEN='a'\''b';`}
+		if len(lines) != len(want)+1 {
+			t.Fatalf("writeEnvDiff() expected %d lines, got %q", len(want)+1, lines)
+		}
+		for i, w := range want {
+			if lines[i] != w {
+				t.Errorf("writeEnvDiff() expected line %d to be %s, got %s", i, w, lines[i])
+			}
+		}
+
+		encoded := strings.TrimSuffix(strings.TrimPrefix(lines[3], "export "+hookStateVar+"='"), "';")
+		saved := decodeHookState(encoded)
+		if saved == nil || saved.Link != state.Link || len(saved.Names) != 2 {
+			t.Errorf("writeEnvDiff() expected the state to be saved, got %s", lines[3])
+		}
+	})
+
+	t.Run("leaving", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if err := writeEnvDiff(buf, "fish", envDiff(prev, nil, nil, noEnv), nil); err != nil {
+			t.Fatalf("writeEnvDiff() expected no errors, got %s", err)
+		}
+
+		want := "set -e OLD;\nset -e PORT;\nset -e " + hookStateVar + ";\n"
+		if buf.String() != want {
+			t.Errorf("writeEnvDiff() expected %q, got %q", want, buf.String())
+		}
+	})
+}
+
+func TestEnvDiffRestoresPrevious(t *testing.T) {
+	env := map[string]string{"PORT": "8080", "HOME": "/home/me"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	state := &hookState{}
+	diff := envDiff(nil, state, map[string]string{"PORT": "80", "TOKEN": "abc"}, lookup)
+	if len(diff) != 2 || *diff["PORT"] != "80" || *diff["TOKEN"] != "abc" {
+		t.Errorf("envDiff() expected the secrets to be loaded, got %v", diff)
+	}
+	if len(state.Previous) != 1 || state.Previous["PORT"] != "8080" {
+		t.Errorf("envDiff() expected the previous PORT to be saved, got %v", state.Previous)
+	}
+	state.Names = []string{"PORT", "TOKEN"}
+
+	// The shell now has the loaded values, which must not be saved as the
+	// previous ones when reloading.
+	env["PORT"] = "80"
+	next := &hookState{}
+	envDiff(state, next, map[string]string{"PORT": "81"}, lookup)
+	if len(next.Previous) != 1 || next.Previous["PORT"] != "8080" {
+		t.Errorf("envDiff() expected the previous PORT to be kept, got %v", next.Previous)
+	}
+	next.Names = []string{"PORT"}
+
+	diff = envDiff(next, nil, nil, lookup)
+	if len(diff) != 1 || diff["PORT"] == nil || *diff["PORT"] != "8080" {
+		t.Errorf("envDiff() expected PORT to be restored, got %v", diff)
+	}
+
+	diff = envDiff(state, nil, nil, lookup)
+	if diff["TOKEN"] != nil {
+		t.Errorf("envDiff() expected TOKEN to be unset, got %v", *diff["TOKEN"])
+	}
+}
+
+func TestDecodeHookState(t *testing.T) {
+	state := &hookState{PathExp: "/o/p/e/s", LoadedAt: time.Now().Round(0)}
+	encoded, err := encodeHookState(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := decodeHookState(encoded); got == nil || got.PathExp != state.PathExp || !got.LoadedAt.Equal(state.LoadedAt) {
+		t.Errorf("decodeHookState() expected %v, got %v", state, got)
+	}
+
+	for _, invalid := range []string{"", "not base64!", "bm90IGpzb24"} {
+		if got := decodeHookState(invalid); got != nil {
+			t.Errorf("decodeHookState(%q) expected nil, got %v", invalid, got)
+		}
+	}
+}
@@ -1,14 +1,21 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/google/shlex"
 	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
 
 	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/errs"
@@ -23,10 +30,15 @@ type secretPair struct {
 func init() {
 	c := cli.Command{
 		Name:      "import",
-		Usage:     "Import multiple secrets from an env file",
+		Usage:     "Import multiple secrets from an env, JSON, or YAML file",
 		ArgsUsage: "[path to file] or use stdin redirection (e.g. `torus import < secrets.env`)",
 		Category:  "SECRETS",
-		Flags:     setUnsetFlags,
+		Flags: append(setUnsetFlags,
+			newPlaceholder("format, f", "FORMAT", "Format of the input, one of env, json or yaml "+
+				"(default: detected from the file extension, or env)", "", "", false),
+			newPlaceholder("separator", "SEPARATOR", "Flatten nested JSON or YAML objects, joining "+
+				"their keys with SEPARATOR (e.g. _)", "", "", false),
+		),
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 			setSliceDefaults, importCmd,
@@ -38,7 +50,7 @@ func init() {
 
 func importCmd(ctx *cli.Context) error {
 	args := ctx.Args()
-	secrets, err := importSecretFile(args)
+	secrets, err := importSecrets(args, ctx.String("format"), ctx.String("separator"))
 
 	if err != nil {
 		return errs.NewUsageExitError(err.Error(), ctx)
@@ -50,12 +62,10 @@ func importCmd(ctx *cli.Context) error {
 	}
 
 	makers := valueMakers{}
-	for _, secret := range secrets {
-		makers[secret.key] = func(value string) valueMaker {
-			return func() *apitypes.CredentialValue {
-				return apitypes.NewStringCredentialValue(value)
-			}
-		}(secret.value)
+	for name, value := range secrets {
+		makers[name] = func(value *apitypes.CredentialValue) valueMaker {
+			return func() *apitypes.CredentialValue { return value }
+		}(value)
 	}
 
 	s, p := spinner("Attempting to set credentials")
@@ -77,21 +87,82 @@ func importCmd(ctx *cli.Context) error {
 	return nil
 }
 
+// importSecrets returns the values of the secrets read from the file given in
+// args, or from the standard input, in the given format. If no format is given,
+// it is detected from the file's extension, falling back to env.
+//
+// Values read from JSON or YAML keep their numeric types. Nested objects are
+// flattened if a separator is given, and are otherwise an error.
+func importSecrets(args []string, format, separator string) (map[string]*apitypes.CredentialValue, error) {
+	if format == "" {
+		format = "env"
+		if len(args) == 1 {
+			switch strings.ToLower(filepath.Ext(args[0])) {
+			case ".json":
+				format = "json"
+			case ".yaml", ".yml":
+				format = "yaml"
+			}
+		}
+	}
+
+	switch format {
+	case "env":
+		pairs, err := importSecretFile(args)
+		if err != nil {
+			return nil, err
+		}
+
+		secrets := make(map[string]*apitypes.CredentialValue, len(pairs))
+		for _, pair := range pairs {
+			secrets[pair.key] = apitypes.NewStringCredentialValue(pair.value)
+		}
+		return secrets, nil
+	case "json", "yaml":
+		r, err := openImportInput(args)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading input. %s", err)
+		}
+
+		return parseStructuredSecrets(data, format, separator)
+	default:
+		return nil, fmt.Errorf("Unknown format %q, expected one of env, json or yaml", format)
+	}
+}
+
 // importSecretFile returns a list of secret pairs either reading a file
 // provided or from the standard input. It returns an error if there is a
 // problem parsing secrets or stdin fails to read.
 func importSecretFile(args []string) ([]secretPair, error) {
+	r, err := openImportInput(args)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return scanSecrets(r)
+}
+
+// openImportInput opens the file given in args, or the standard input if no
+// file is given.
+func openImportInput(args []string) (io.ReadCloser, error) {
 	switch len(args) {
 	case 0:
-		return readStdin()
+		return openStdin()
 	case 1:
-		return readFile(args[0])
+		return openFile(args[0])
 	default:
 		return nil, errors.New("Too many arguments were provided")
 	}
 }
 
-func readFile(filename string) ([]secretPair, error) {
+func openFile(filename string) (io.ReadCloser, error) {
 	flags := os.O_RDONLY
 	f, err := os.OpenFile(filename, flags, 0644)
 	if os.IsNotExist(err) {
@@ -101,22 +172,22 @@ func readFile(filename string) ([]secretPair, error) {
 		return nil, fmt.Errorf("Error reading %s file %s", filename, err)
 	}
 
-	defer f.Close()
-
-	return scanSecrets(f)
+	return f, nil
 }
 
-func readStdin() ([]secretPair, error) {
+func openStdin() (io.ReadCloser, error) {
 	stat, err := os.Stdin.Stat()
 	if err != nil {
-		return nil, fmt.Errorf("Could not from stdin. %s", err)
+		return nil, fmt.Errorf("Could not read from stdin. %s", err)
 	}
 
-	if (stat.Mode() & os.ModeNamedPipe) != 0 {
-		return nil, errors.New("Could not read from piped input")
+	// Without a file, redirection, or pipe, we'd wait on the user to type
+	// their secrets in.
+	if (stat.Mode() & os.ModeCharDevice) != 0 {
+		return nil, errors.New("A file, redirected input, or piped input is required")
 	}
 
-	return scanSecrets(os.Stdin)
+	return ioutil.NopCloser(os.Stdin), nil
 }
 
 // scanSecrets reads secret pairs using an UNIX shell-like syntax parser. Empty
@@ -150,3 +221,119 @@ func scanSecrets(r io.Reader) ([]secretPair, error) {
 		pairs = append(pairs, secretPair{key: key, value: value})
 	}
 }
+
+// parseStructuredSecrets reads secrets from a JSON or YAML object. Integers
+// and floats keep their types, while booleans are stored as strings.
+//
+// Nested objects are flattened by joining their keys with the separator. If
+// the separator is empty, they are an error.
+func parseStructuredSecrets(data []byte, format, separator string) (map[string]*apitypes.CredentialValue, error) {
+	var raw map[string]interface{}
+
+	switch format {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("Error parsing JSON input. %s", err)
+		}
+	case "yaml":
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("Error parsing YAML input. %s", err)
+		}
+	}
+
+	secrets := make(map[string]*apitypes.CredentialValue)
+	err := flattenSecrets(secrets, "", raw, separator)
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+func flattenSecrets(secrets map[string]*apitypes.CredentialValue, prefix string,
+	obj map[string]interface{}, separator string) error {
+
+	// Sort the keys so errors are reported consistently.
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := prefix + k
+
+		var nested map[string]interface{}
+		switch v := obj[k].(type) {
+		case map[string]interface{}:
+			nested = v
+		case map[interface{}]interface{}:
+			nested = make(map[string]interface{}, len(v))
+			for nk, nv := range v {
+				nested[fmt.Sprintf("%v", nk)] = nv
+			}
+		}
+
+		if nested != nil {
+			if separator == "" {
+				return fmt.Errorf("Secret %q is a nested object; use --separator to flatten it", key)
+			}
+
+			err := flattenSecrets(secrets, key+separator, nested, separator)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := importValue(key, obj[k])
+		if err != nil {
+			return err
+		}
+
+		// Secret names are stored in lowercase, so keys differing only by
+		// case would set the same secret.
+		for existing := range secrets {
+			if strings.EqualFold(existing, key) {
+				return fmt.Errorf("Secret %q is defined more than once", key)
+			}
+		}
+		secrets[key] = value
+	}
+
+	return nil
+}
+
+func importValue(key string, v interface{}) (*apitypes.CredentialValue, error) {
+	switch value := v.(type) {
+	case string:
+		if value == "" {
+			return nil, fmt.Errorf("Secret %q has an empty value", key)
+		}
+		return apitypes.NewStringCredentialValue(value), nil
+	case bool:
+		return apitypes.NewStringCredentialValue(strconv.FormatBool(value)), nil
+	case int:
+		return apitypes.NewIntCredentialValue(value), nil
+	case json.Number:
+		if i, err := strconv.Atoi(value.String()); err == nil {
+			return apitypes.NewIntCredentialValue(i), nil
+		}
+
+		f, err := value.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("Secret %q has an invalid number %s", key, value)
+		}
+		return apitypes.NewFloatCredentialValue(f), nil
+	case float64:
+		return apitypes.NewFloatCredentialValue(value), nil
+	case nil:
+		return nil, fmt.Errorf("Secret %q has no value", key)
+	case []interface{}:
+		return nil, fmt.Errorf("Secret %q is a list, which is not supported", key)
+	default:
+		return nil, fmt.Errorf("Secret %q has an unsupported value %v", key, value)
+	}
+}
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -138,3 +140,133 @@ func TestScanSecrets(t *testing.T) {
 		}
 	}
 }
+
+func TestImportSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "torus-import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "secrets.json")
+	err = ioutil.WriteFile(file, []byte(`{"port": 5432, "ratio": 0.5, "name": "db"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("detects the format", func(t *testing.T) {
+		secrets, err := importSecrets([]string{file}, "", "")
+		if err != nil {
+			t.Fatalf("importSecrets(%v) expected no errors, got %q", file, err)
+		}
+
+		if raw, _ := secrets["port"].Raw(); raw != 5432 {
+			t.Errorf("importSecrets(%v) expected port to be an int, got %#v", file, raw)
+		}
+	})
+
+	t.Run("explicit format", func(t *testing.T) {
+		_, err := importSecrets([]string{file}, "env", "")
+		if err == nil {
+			t.Errorf("importSecrets(%v) expected an error parsing JSON as env", file)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := importSecrets([]string{file}, "xml", "")
+		if err == nil {
+			t.Errorf("importSecrets(%v) expected an error for an unknown format", file)
+		}
+	})
+}
+
+func TestParseStructuredSecrets(t *testing.T) {
+	testCases := []struct {
+		format    string
+		content   string
+		separator string
+		values    map[string]interface{}
+		err       string
+	}{
+		{
+			format:  "json",
+			content: `{"port": 5432, "ratio": 0.5, "name": "db", "debug": true}`,
+			values: map[string]interface{}{
+				"port": 5432, "ratio": 0.5, "name": "db", "debug": "true",
+			},
+		},
+		{
+			format:    "json",
+			content:   `{"db": {"user": "admin", "pool": {"size": 10}}}`,
+			separator: "_",
+			values: map[string]interface{}{
+				"db_user": "admin", "db_pool_size": 10,
+			},
+		},
+		{
+			format:  "json",
+			content: `{"db": {"user": "admin"}}`,
+			err:     `Secret "db" is a nested object; use --separator to flatten it`,
+		},
+		{
+			format:  "json",
+			content: `{"hosts": ["a", "b"]}`,
+			err:     `Secret "hosts" is a list, which is not supported`,
+		},
+		{
+			format:  "yaml",
+			content: "port: 5432\nratio: 1.5\nname: db\n",
+			values: map[string]interface{}{
+				"port": 5432, "ratio": 1.5, "name": "db",
+			},
+		},
+		{
+			format:    "yaml",
+			content:   "db:\n  user: admin\n  port: 5432\n",
+			separator: ".",
+			values: map[string]interface{}{
+				"db.user": "admin", "db.port": 5432,
+			},
+		},
+		{
+			format:  "yaml",
+			content: "name:\n",
+			err:     `Secret "name" has no value`,
+		},
+		{
+			format:    "json",
+			content:   `{"db_user": "a", "db": {"user": "b"}}`,
+			separator: "_",
+			err:       `Secret "db_user" is defined more than once`,
+		},
+		{
+			format:  "json",
+			content: `{"Foo": 1, "foo": 2}`,
+			err:     `Secret "foo" is defined more than once`,
+		},
+	}
+
+	for _, tc := range testCases {
+		secrets, err := parseStructuredSecrets([]byte(tc.content), tc.format, tc.separator)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("parseStructuredSecrets(%v) expected error %q, got %v", tc.content, tc.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseStructuredSecrets(%v) expected no errors, got %q", tc.content, err)
+			continue
+		}
+
+		got := make(map[string]interface{}, len(secrets))
+		for k, v := range secrets {
+			got[k], _ = v.Raw()
+		}
+
+		if !reflect.DeepEqual(tc.values, got) {
+			t.Errorf("parseStructuredSecrets(%v) expected %v, got %v", tc.content, tc.values, got)
+		}
+	}
+}
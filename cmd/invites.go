@@ -30,10 +30,11 @@ func init() {
 						Name:  "approved, a",
 						Usage: "Show only approved invites",
 					},
+					outputFlag,
 				},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					checkRequiredFlags, invitesList,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, checkRequiredFlags, invitesList,
 				),
 			},
 			{
@@ -17,6 +17,16 @@ import (
 	"github.com/manifoldco/torus-cli/ui"
 )
 
+// inviteOutput is the structured output of an org invite
+type inviteOutput struct {
+	Email     string     `json:"email"`
+	Name      string     `json:"name,omitempty"`
+	Username  string     `json:"username,omitempty"`
+	State     string     `json:"state"`
+	InvitedBy string     `json:"invited_by"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
 func invitesList(ctx *cli.Context) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -44,6 +54,9 @@ func invitesList(ctx *cli.Context) error {
 	}
 
 	if len(invites) < 1 {
+		if structuredOutput(ctx) {
+			return writeOutput(ctx, os.Stdout, []inviteOutput{})
+		}
 		fmt.Println("No invites found.")
 		return nil
 	}
@@ -77,6 +90,29 @@ func invitesList(ctx *cli.Context) error {
 		usernameByID[profile.ID.String()] = profile.Body.Username
 	}
 
+	if structuredOutput(ctx) {
+		out := []inviteOutput{}
+		for _, invite := range invites {
+			inviter := usernameByID[invite.Body.InviterID.String()]
+			if inviter == "" {
+				continue
+			}
+
+			o := inviteOutput{
+				Email:     invite.Body.Email,
+				State:     invite.Body.State,
+				InvitedBy: inviter,
+				CreatedAt: invite.Body.Created,
+			}
+			if invite.Body.InviteeID != nil {
+				o.Name = nameByID[invite.Body.InviteeID.String()]
+				o.Username = usernameByID[invite.Body.InviteeID.String()]
+			}
+			out = append(out, o)
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	fmt.Println("")
 	if ctx.Bool("approved") {
 		fmt.Println("Listing approved invitations for org " + org.Body.Name)
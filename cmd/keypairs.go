@@ -28,10 +28,11 @@ func init() {
 				Usage: "List your keypairs for an organization",
 				Flags: []cli.Flag{
 					orgFlag("org to show keypairs for", true),
+					outputFlag,
 				},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					checkRequiredFlags, listKeypairs,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, checkRequiredFlags, listKeypairs,
 				),
 			},
 			{
@@ -69,6 +70,15 @@ func init() {
 
 const keypairListFailed = "Could not list keypairs, please try again."
 
+// keypairOutput is the structured output of a keypair
+type keypairOutput struct {
+	ID        string    `json:"id"`
+	Org       string    `json:"org"`
+	KeyType   string    `json:"key_type"`
+	Valid     bool      `json:"valid"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 func listKeypairs(ctx *cli.Context) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -93,6 +103,21 @@ func listKeypairs(ctx *cli.Context) error {
 		return errs.NewExitError(keypairListFailed)
 	}
 
+	if structuredOutput(ctx) {
+		out := []keypairOutput{}
+		for _, keypair := range keypairs.All() {
+			pk := keypair.PublicKey.Body
+			out = append(out, keypairOutput{
+				ID:        keypair.PublicKey.ID.String(),
+				Org:       org.Body.Name,
+				KeyType:   string(pk.KeyType),
+				Valid:     !keypair.Revoked(),
+				CreatedAt: pk.Created,
+			})
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	fmt.Println("")
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/juju/ansiterm"
 	"github.com/urfave/cli"
@@ -34,11 +36,13 @@ func init() {
 			teamSliceFlag("Filter credentials against this team.", false),
 			cli.BoolFlag{
 				Name:  "verbose, v",
-				Usage: "Display the full credential path of each secret.",
+				Usage: "Display the full credential path and metadata of each secret.",
 			},
+			outputFlag,
 		},
 		Action: chain(
-			ensureDaemon, ensureSession, loadDirPrefs, checkRequiredFlags, listCmd,
+			checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+			checkRequiredFlags, listCmd,
 		),
 	}
 	Cmds = append(Cmds, list)
@@ -252,6 +256,19 @@ func listCmd(ctx *cli.Context) error {
 		}
 	}
 
+	var owners map[identity.ID]string
+	if verbose || structuredOutput(ctx) {
+		owners, err = credentialOwners(c, client, credentials)
+		if err != nil {
+			return errs.NewErrorExitError("Could not retrieve secret owners.", err)
+		}
+	}
+	now := time.Now()
+
+	if structuredOutput(ctx) {
+		return writeOutput(ctx, os.Stdout, listOutput(tree, owners, now))
+	}
+
 	fmt.Println("")
 	w := ansiterm.NewTabWriter(os.Stdout, 0, 0, 0, ' ', 0)
 	for e := range tree {
@@ -269,6 +286,9 @@ func listCmd(ctx *cli.Context) error {
 				if verbose {
 					credPath := displayPathExp((*cred.Body).GetPathExp()) + "/"
 					fmt.Fprintf(w, "\t\t%s\t (%s)\t\n", c, ui.FaintString(credPath+c))
+					if m := formatCredentialMetadata((*cred.Body).GetMetadata(), owners, now); m != "" {
+						fmt.Fprintf(w, "\t\t\t %s\t\n", m)
+					}
 				} else {
 					fmt.Fprintf(w, "\t\t%s\t\t\n", c)
 				}
@@ -282,6 +302,57 @@ func listCmd(ctx *cli.Context) error {
 	return nil
 }
 
+// listSecretOutput is the structured output of a secret found by list.
+// Unlike secretOutput, it has no value, as list never displays values.
+type listSecretOutput struct {
+	Environment string                `json:"environment"`
+	Service     string                `json:"service"`
+	Name        string                `json:"name"`
+	Path        string                `json:"path"`
+	Metadata    *secretMetadataOutput `json:"metadata,omitempty"`
+}
+
+// listOutput returns the secrets in the tree, ordered by environment, service
+// and name. Secrets found under more than one environment or service are
+// listed under each.
+func listOutput(tree credentialTree, owners map[identity.ID]string, now time.Time) []listSecretOutput {
+	envs := make([]string, 0, len(tree))
+	for e := range tree {
+		envs = append(envs, e)
+	}
+	sort.Strings(envs)
+
+	out := []listSecretOutput{}
+	for _, e := range envs {
+		services := make([]string, 0, len(tree[e]))
+		for s := range tree[e] {
+			services = append(services, s)
+		}
+		sort.Strings(services)
+
+		for _, s := range services {
+			names := make([]string, 0, len(tree[e][s]))
+			for name := range tree[e][s] {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				body := *tree[e][s][name].Body
+				out = append(out, listSecretOutput{
+					Environment: e,
+					Service:     s,
+					Name:        body.GetName(),
+					Path:        displayPathExp(body.GetPathExp()) + "/" + body.GetName(),
+					Metadata:    newSecretMetadataOutput(body.GetMetadata(), owners, now),
+				})
+			}
+		}
+	}
+
+	return out
+}
+
 func isSecretNameInList(secret string, list []string) bool {
 	for _, s := range list {
 		if s == secret {
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestListOutput(t *testing.T) {
+	pe, err := pathexp.Parse("/o/p/e/s/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body apitypes.Credential = &apitypes.CredentialV3{
+		CredentialV2: apitypes.CredentialV2{
+			State: "set",
+			BaseCredential: apitypes.BaseCredential{
+				Name:    "password",
+				PathExp: pe,
+				Value:   apitypes.NewStringCredentialValue("hunter2"),
+			},
+		},
+		Metadata: &primitive.CredentialMetadata{Description: "Admin password"},
+	}
+
+	tree := credentialTree{"e": serviceCredentialMap{"s": credentialSet{}}}
+	if err := tree["e"]["s"].Add(apitypes.CredentialEnvelope{Body: &body}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := listOutput(tree, nil, time.Now())
+	if len(out) != 1 || out[0].Name != "password" || out[0].Path != "/o/p/e/s/password" ||
+		out[0].Metadata == nil || out[0].Metadata.Description != "Admin password" {
+		t.Fatalf("listOutput() got unexpected output %#v", out)
+	}
+
+	raw, err := json.Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "hunter2") || strings.Contains(string(raw), `"value"`) {
+		t.Errorf("listOutput() expected no values, got %s", raw)
+	}
+}
@@ -81,10 +81,11 @@ func init() {
 					orgFlag("Org the machine belongs to", false),
 					roleFlag("List machines of this role", false),
 					destroyedFlag(),
+					outputFlag,
 				},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					checkRequiredFlags, listMachinesCmd,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, checkRequiredFlags, listMachinesCmd,
 				),
 			},
 			{
@@ -93,10 +94,11 @@ func init() {
 				ArgsUsage: "<id|name>",
 				Flags: []cli.Flag{
 					orgFlag("Org the machine will belongs to", false),
+					outputFlag,
 				},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					checkRequiredFlags, viewMachineCmd,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, checkRequiredFlags, viewMachineCmd,
 				),
 			},
 			{
@@ -134,10 +136,11 @@ func init() {
 						Usage: "List all machine roles for an organization",
 						Flags: []cli.Flag{
 							orgFlag("Org the machine roles belongs to", false),
+							outputFlag,
 						},
 						Action: chain(
-							ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-							checkRequiredFlags, listMachineRoles,
+							checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+							loadPrefDefaults, checkRequiredFlags, listMachineRoles,
 						),
 					},
 				},
@@ -220,6 +223,35 @@ func destroyMachineCmd(ctx *cli.Context) error {
 	return nil
 }
 
+// machineOutput is the structured output of a machine. Tokens are only
+// included when viewing a single machine.
+type machineOutput struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	State       string               `json:"state"`
+	Roles       []string             `json:"roles"`
+	CreatedBy   string               `json:"created_by,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+	DestroyedBy string               `json:"destroyed_by,omitempty"`
+	DestroyedAt *time.Time           `json:"destroyed_at,omitempty"`
+	Tokens      []machineTokenOutput `json:"tokens,omitempty"`
+}
+
+// machineTokenOutput is the structured output of a machine token
+type machineTokenOutput struct {
+	ID        string    `json:"id"`
+	State     string    `json:"state"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// machineRoleOutput is the structured output of a machine role
+type machineRoleOutput struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	System bool   `json:"system"`
+}
+
 func viewMachineCmd(ctx *cli.Context) error {
 	args := ctx.Args()
 	if len(args) > 1 {
@@ -307,6 +339,32 @@ func viewMachineCmd(ctx *cli.Context) error {
 			teamNames = append(teamNames, team.Body.Name)
 		}
 	}
+
+	if structuredOutput(ctx) {
+		out := machineOutput{
+			ID:        machine.ID.String(),
+			Name:      machineBody.Name,
+			State:     machineBody.State,
+			Roles:     append([]string{}, teamNames...),
+			CreatedBy: creator.Body.Username,
+			CreatedAt: machineBody.Created,
+			Tokens:    make([]machineTokenOutput, len(machineSegment.Tokens)),
+		}
+		if machineBody.State == primitive.MachineDestroyedState {
+			out.DestroyedBy = profileMap[*machineBody.DestroyedBy].Body.Username
+			out.DestroyedAt = machineBody.Destroyed
+		}
+		for i, token := range machineSegment.Tokens {
+			out.Tokens[i] = machineTokenOutput{
+				ID:        token.Token.ID.String(),
+				State:     token.Token.Body.State,
+				CreatedBy: profileMap[*token.Token.Body.CreatedBy].Body.Username,
+				CreatedAt: token.Token.Body.Created,
+			}
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	roleOutput := strings.Join(teamNames, ", ")
 	if roleOutput == "" {
 		roleOutput = "-"
@@ -405,7 +463,7 @@ func listMachinesCmd(ctx *cli.Context) error {
 		return err
 	}
 
-	if len(machines) == 0 {
+	if len(machines) == 0 && !structuredOutput(ctx) {
 		fmt.Println("No machines found.")
 		return nil
 	}
@@ -417,6 +475,26 @@ func listMachinesCmd(ctx *cli.Context) error {
 		}
 	}
 
+	if structuredOutput(ctx) {
+		out := make([]machineOutput, len(machines))
+		for i, machine := range machines {
+			m := machine.Machine.Body
+			out[i] = machineOutput{
+				ID:        machine.Machine.ID.String(),
+				Name:      m.Name,
+				State:     m.State,
+				Roles:     []string{},
+				CreatedAt: m.Created,
+			}
+			for _, membership := range machine.Memberships {
+				if role, ok := roleMap[*membership.Body.TeamID]; ok {
+					out[i].Roles = append(out[i].Roles, role.Name)
+				}
+			}
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	fmt.Println("")
 	w := ansiterm.NewTabWriter(os.Stdout, 2, 0, 3, ' ', 0)
 	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", ui.BoldString("ID"), ui.BoldString("Name"), ui.BoldString("State"), ui.BoldString("Role"), ui.BoldString("Creation Date"))
@@ -495,6 +573,20 @@ func listMachineRoles(ctx *cli.Context) error {
 		return errs.NewErrorExitError("Failed to retrieve roles", err)
 	}
 
+	if structuredOutput(ctx) {
+		out := []machineRoleOutput{}
+		for _, t := range teams {
+			if isMachineTeam(t.Body) {
+				out = append(out, machineRoleOutput{
+					ID:     t.ID.String(),
+					Name:   t.Body.Name,
+					System: t.Body.TeamType == primitive.SystemTeamType && t.Body.Name == primitive.MachineTeamName,
+				})
+			}
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	w := tabwriter.NewWriter(os.Stdout, 20, 0, 1, ' ', 0)
 	for _, t := range teams {
 		if !isMachineTeam(t.Body) {
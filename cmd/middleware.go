@@ -169,7 +169,9 @@ func ensureSession(ctx *cli.Context) error {
 	return errs.NewExitError(msg)
 }
 
-// loadDirPrefs loads argument values from the .torus.json file
+// loadDirPrefs loads argument values from the .torus.json file. If the
+// current git branch is mapped to an environment, it is used in place of the
+// file's environment.
 func loadDirPrefs(ctx *cli.Context) error {
 	p, err := prefs.NewPreferences()
 	if err != nil {
@@ -181,7 +183,15 @@ func loadDirPrefs(ctx *cli.Context) error {
 		return err
 	}
 
-	return reflectArgs(ctx, p, d, "json")
+	if len(d.Branches) > 0 {
+		if env := d.BranchEnvironment(d.CurrentBranch()); env != "" {
+			d.Environment = env
+		}
+	}
+
+	// The environment and service in .torus.json take precedence over the
+	// flags' default values.
+	return reflectArgs(ctx, p, d, "json", "environment", "service")
 }
 
 // loadPrefDefaults loads default argument values from the .torusrc
@@ -195,8 +205,11 @@ func loadPrefDefaults(ctx *cli.Context) error {
 	return reflectArgs(ctx, p, p.Defaults, "ini")
 }
 
+// reflectArgs sets the value of each unset flag from the field of i tagged
+// with the flag's name. A flag holding its default value is considered set,
+// unless it's one of the named overrideDefaults.
 func reflectArgs(ctx *cli.Context, p *prefs.Preferences, i interface{},
-	tagName string) error {
+	tagName string, overrideDefaults ...string) error {
 
 	// The user has disabled reading arguments from prefs and .torus.json
 	if !p.Core.Context {
@@ -212,7 +225,11 @@ func reflectArgs(ctx *cli.Context, p *prefs.Preferences, i interface{},
 	flags := make(map[string]bool)
 	for _, flagName := range ctx.FlagNames() {
 		// This value is already set via arguments or env vars. skip it.
-		if isSet(ctx, flagName) {
+		if containsString(overrideDefaults, flagName) {
+			if ctx.IsSet(flagName) {
+				continue
+			}
+		} else if isSet(ctx, flagName) {
 			continue
 		}
 
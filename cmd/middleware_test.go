@@ -135,6 +135,38 @@ func TestReflectArgs(t *testing.T) {
 			t.Error("loadPrefDefaults did not set argument")
 		}
 	})
+
+	t.Run("Does not overwrite default values", func(t *testing.T) {
+		flagset := flag.NewFlagSet("", flag.ContinueOnError)
+		flagset.String("org", "default", "")
+		ctx := cli.NewContext(nil, flagset, nil)
+		ctx.Command = cmd
+
+		err := reflectArgs(ctx, p, p.Defaults, "ini")
+		if err != nil {
+			t.Error("loadPrefDefaults errored: " + err.Error())
+		}
+
+		if ctx.String("org") != "default" {
+			t.Error("loadPrefDefaults replaced the default value")
+		}
+	})
+
+	t.Run("Overwrites named default values", func(t *testing.T) {
+		flagset := flag.NewFlagSet("", flag.ContinueOnError)
+		flagset.String("org", "default", "")
+		ctx := cli.NewContext(nil, flagset, nil)
+		ctx.Command = cmd
+
+		err := reflectArgs(ctx, p, p.Defaults, "ini", "org")
+		if err != nil {
+			t.Error("loadPrefDefaults errored: " + err.Error())
+		}
+
+		if ctx.String("org") != "org thing" {
+			t.Error("loadPrefDefaults did not replace the default value")
+		}
+	})
 }
 
 func TestCheckRequiredFlags(t *testing.T) {
@@ -6,6 +6,7 @@ import (
 	"os"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/juju/ansiterm"
@@ -38,7 +39,8 @@ func init() {
 			{
 				Name:   "list",
 				Usage:  "List organizations associated with your account",
-				Action: chain(ensureDaemon, ensureSession, orgsListCmd),
+				Flags:  []cli.Flag{outputFlag},
+				Action: chain(checkOutputFormat, ensureDaemon, ensureSession, orgsListCmd),
 			},
 			{
 				Name:      "remove",
@@ -57,10 +59,11 @@ func init() {
 				Usage: "List all members in an org",
 				Flags: []cli.Flag{
 					orgFlag("Use this organization.", false),
+					outputFlag,
 				},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					orgsMembersListCmd,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, orgsMembersListCmd,
 				),
 			},
 		},
@@ -123,12 +126,39 @@ func createOrgByName(c context.Context, ctx *cli.Context, client *api.Client, na
 	return org, nil
 }
 
+// orgOutput is the structured output of an org
+type orgOutput struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Personal bool   `json:"personal"`
+}
+
+// memberOutput is the structured output of an org or team member
+type memberOutput struct {
+	Name     string   `json:"name"`
+	Username string   `json:"username"`
+	Self     bool     `json:"self"`
+	Teams    []string `json:"teams,omitempty"`
+}
+
 func orgsListCmd(ctx *cli.Context) error {
 	orgs, session, err := orgsList()
 	if err != nil {
 		return err
 	}
 
+	if structuredOutput(ctx) {
+		out := make([]orgOutput, len(orgs))
+		for i, o := range orgs {
+			out[i] = orgOutput{
+				ID:       o.ID.String(),
+				Name:     o.Body.Name,
+				Personal: session.Type() == apitypes.UserSession && o.Body.Name == session.Username(),
+			}
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	withoutPersonal := orgs
 
 	fmt.Println("")
@@ -370,15 +400,8 @@ func orgsMembersListCmd(ctx *cli.Context) error {
 		return errs.NewExitError("User not found.")
 	}
 
-	fmt.Println("")
-	w := ansiterm.NewTabWriter(os.Stdout, 2, 0, 3, ' ', 0)
-	fmt.Fprintf(w, "\t%s\t%s\t%s\n", ui.BoldString("Name"), ui.BoldString("Username"), ui.BoldString("Team"))
-	for _, user := range users {
-		me := ""
-		if session.Username() == user.Body.Username {
-			me = ui.FaintString("*")
-		}
-
+	out := make([]memberOutput, len(users))
+	for i, user := range users {
 		// Sort teams by precedence
 		userTeams := []envelope.Team{}
 		for _, teamID := range userTeamIdx[*user.ID] {
@@ -387,14 +410,30 @@ func orgsMembersListCmd(ctx *cli.Context) error {
 
 		sort.Sort(ByTeamPrecedence(userTeams))
 
-		// Create string containing all team names associated with each user
-		teamString := ""
+		out[i] = memberOutput{
+			Name:     user.Body.Name,
+			Username: user.Body.Username,
+			Self:     session.Username() == user.Body.Username,
+		}
 		for _, t := range userTeams {
-			teamString += t.Body.Name + ", "
+			out[i].Teams = append(out[i].Teams, t.Body.Name)
+		}
+	}
+
+	if structuredOutput(ctx) {
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
+	fmt.Println("")
+	w := ansiterm.NewTabWriter(os.Stdout, 2, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "\t%s\t%s\t%s\n", ui.BoldString("Name"), ui.BoldString("Username"), ui.BoldString("Team"))
+	for _, m := range out {
+		me := ""
+		if m.Self {
+			me = ui.FaintString("*")
 		}
-		// Remove trailing comma and space character
-		teamString = teamString[:len(teamString)-2]
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", me, user.Body.Name, ui.FaintString(user.Body.Username), teamString)
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", me, m.Name, ui.FaintString(m.Username), strings.Join(m.Teams, ", "))
 	}
 
 	w.Flush()
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+// outputFormats are the values accepted by --output. Tables are meant for
+// people; json and yaml output a stable structure for use by scripts.
+var outputFormats = []string{"table", "json", "yaml"}
+
+// outputFlag selects the output format of list and view commands. It can be
+// given before the command, or to the command itself.
+var outputFlag = newPlaceholder("output", "FORMAT",
+	"Output format ("+strings.Join(outputFormats, ", ")+")", "table", "TORUS_OUTPUT", false)
+
+// GlobalFlags are the flags accepted by torus before any command.
+var GlobalFlags = []cli.Flag{outputFlag}
+
+// outputFormat returns the output format given to the command, or before it.
+func outputFormat(ctx *cli.Context) string {
+	if ctx.IsSet("output") {
+		return ctx.String("output")
+	}
+	if format := ctx.GlobalString("output"); format != "" {
+		return format
+	}
+
+	return "table"
+}
+
+// structuredOutput reports whether the command should write structured
+// output, rather than a table.
+func structuredOutput(ctx *cli.Context) bool {
+	return outputFormat(ctx) != "table"
+}
+
+// checkOutputFormat ensures the output format is one we know how to write,
+// before a command does any work.
+func checkOutputFormat(ctx *cli.Context) error {
+	format := outputFormat(ctx)
+	for _, f := range outputFormats {
+		if f == format {
+			return nil
+		}
+	}
+
+	return errs.NewUsageExitError("Unknown output format: "+format, ctx)
+}
+
+// writeOutput writes v to w in the command's structured output format.
+func writeOutput(ctx *cli.Context, w io.Writer, v interface{}) error {
+	return writeStructured(w, outputFormat(ctx), v)
+}
+
+// writeStructured writes v to w as json or yaml. Both are written from the
+// json encoding of v, so they share the same names and field order.
+func writeStructured(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		ordered, err := orderedYAML(dec)
+		if err != nil {
+			return err
+		}
+
+		out, err := yaml.Marshal(ordered)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(out)
+		return err
+	default:
+		return fmt.Errorf("Cannot write %s output", format)
+	}
+}
+
+// orderedYAML decodes the next json value from dec into a value which yaml
+// marshals with its fields in the same order.
+func orderedYAML(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		var out interface{}
+		switch t {
+		case '{':
+			m := yaml.MapSlice{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := orderedYAML(dec)
+				if err != nil {
+					return nil, err
+				}
+
+				m = append(m, yaml.MapItem{Key: key, Value: value})
+			}
+			out = m
+		default:
+			l := []interface{}{}
+			for dec.More() {
+				value, err := orderedYAML(dec)
+				if err != nil {
+					return nil, err
+				}
+
+				l = append(l, value)
+			}
+			out = l
+		}
+
+		// Consume the closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return out, nil
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	default:
+		return tok, nil
+	}
+}
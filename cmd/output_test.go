@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func outputContext(global, local string) *cli.Context {
+	app := cli.NewApp()
+
+	globalSet := flag.NewFlagSet("", flag.ContinueOnError)
+	globalSet.String("output", "table", "")
+	if global != "" {
+		globalSet.Set("output", global)
+	}
+	parent := cli.NewContext(app, globalSet, nil)
+
+	localSet := flag.NewFlagSet("", flag.ContinueOnError)
+	localSet.String("output", "table", "")
+	if local != "" {
+		localSet.Set("output", local)
+	}
+
+	return cli.NewContext(app, localSet, parent)
+}
+
+func TestOutputFormat(t *testing.T) {
+	tcs := []struct {
+		global string
+		local  string
+		want   string
+	}{
+		{"", "", "table"},
+		{"json", "", "json"},
+		{"", "yaml", "yaml"},
+		{"json", "yaml", "yaml"},
+	}
+
+	for _, tc := range tcs {
+		ctx := outputContext(tc.global, tc.local)
+		if got := outputFormat(ctx); got != tc.want {
+			t.Errorf("outputFormat(%q, %q) expected %q, got %q", tc.global, tc.local, tc.want, got)
+		}
+	}
+}
+
+func TestCheckOutputFormat(t *testing.T) {
+	if err := checkOutputFormat(outputContext("", "json")); err != nil {
+		t.Errorf("checkOutputFormat() expected no error, got %s", err)
+	}
+
+	ctx := outputContext("", "xml")
+	ctx.Command = cli.Command{Name: "list"}
+	if err := checkOutputFormat(ctx); err == nil {
+		t.Error("checkOutputFormat() expected an error for an unknown format")
+	}
+}
+
+func TestWriteOutput(t *testing.T) {
+	v := []orgOutput{{ID: "1", Name: "org", Personal: true}}
+
+	tcs := []struct {
+		format string
+		want   string
+	}{
+		{"json", "[\n  {\n    \"id\": \"1\",\n    \"name\": \"org\",\n    \"personal\": true\n  }\n]\n"},
+		{"yaml", "- id: \"1\"\n  name: org\n  personal: true\n"},
+	}
+
+	for _, tc := range tcs {
+		buf := &bytes.Buffer{}
+		if err := writeOutput(outputContext("", tc.format), buf, v); err != nil {
+			t.Fatalf("writeOutput(%s) expected no error, got %s", tc.format, err)
+		}
+
+		if buf.String() != tc.want {
+			t.Errorf("writeOutput(%s) expected %q, got %q", tc.format, tc.want, buf.String())
+		}
+	}
+}
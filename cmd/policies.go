@@ -33,10 +33,11 @@ func init() {
 				Usage: "List all policies for an organization",
 				Flags: []cli.Flag{
 					orgFlag("The org to show policies for", false),
+					outputFlag,
 				},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					checkRequiredFlags, listPoliciesCmd,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, checkRequiredFlags, listPoliciesCmd,
 				),
 			},
 			{
@@ -45,10 +46,11 @@ func init() {
 				ArgsUsage: "<policy>",
 				Flags: []cli.Flag{
 					orgFlag("The org the policy belongs to", false),
+					outputFlag,
 				},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					checkRequiredFlags, viewPolicyCmd,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, checkRequiredFlags, viewPolicyCmd,
 				),
 			},
 
@@ -78,6 +80,66 @@ func init() {
 				),
 			},
 
+			{
+				Name:      "export",
+				Usage:     "Export the policies of an organization, and their attachments, to a policy file",
+				ArgsUsage: "[path to file] or use stdout redirection (e.g. `torus policies export > policies.yaml`)",
+				Flags: []cli.Flag{
+					orgFlag("The org to export the policies of", false),
+					newPlaceholder("format", "FORMAT", "Format of the policy file, yaml or json "+
+						"(default: detected from the file extension, or yaml)", "", "", false),
+					cli.BoolFlag{
+						Name:  "system",
+						Usage: "Include system policies, which can't be applied",
+					},
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, exportPoliciesCmd,
+				),
+			},
+
+			{
+				Name:  "plan",
+				Usage: "Show the changes needed to make an org's policies match a policy file",
+				Flags: []cli.Flag{
+					orgFlag("The org to compare the policies of", true),
+					newPlaceholder("file, f", "FILE", "The policy file, in YAML or JSON", "", "", true),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, planPoliciesCmd,
+				),
+			},
+
+			{
+				Name:  "apply",
+				Usage: "Create, update and delete policies, and their attachments, to match a policy file",
+				Flags: []cli.Flag{
+					stdAutoAcceptFlag,
+					orgFlag("The org to apply the policies to", true),
+					newPlaceholder("file, f", "FILE", "The policy file, in YAML or JSON", "", "", true),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, applyPoliciesCmd,
+				),
+			},
+
+			{
+				Name:  "lint",
+				Usage: "Check the policies of an org for shadowed, redundant, duplicate and overly broad statements",
+				Flags: []cli.Flag{
+					orgFlag("The org to lint the policies of", false),
+					newPlaceholder("fail-on", "SEVERITY", "Exit with a non-zero status for findings of this "+
+						"severity or above ("+strings.Join(lintSeverities, ", ")+")", "warning", "", false),
+					outputFlag,
+				},
+				Action: chain(
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, checkRequiredFlags, lintPoliciesCmd,
+				),
+			},
 			{
 				Name:      "delete",
 				Usage:     "Delete a policy from the organization",
@@ -311,6 +373,24 @@ func deletePolicyCmd(ctx *cli.Context) error {
 	return nil
 }
 
+// policyOutput is the structured output of a policy. Statements are only
+// included when viewing a single policy.
+type policyOutput struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Type        string            `json:"type"`
+	AttachedTo  []string          `json:"attached_to,omitempty"`
+	Statements  []statementOutput `json:"statements,omitempty"`
+}
+
+// statementOutput is the structured output of a policy statement
+type statementOutput struct {
+	Effect   string `json:"effect"`
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+}
+
 func listPoliciesCmd(ctx *cli.Context) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -382,6 +462,22 @@ func listPoliciesCmd(ctx *cli.Context) error {
 	}()
 
 	display.Wait()
+
+	if structuredOutput(ctx) {
+		out := make([]policyOutput, len(sortedNames))
+		for i, name := range sortedNames {
+			policy := policiesByName[name]
+			out[i] = policyOutput{
+				ID:          policy.ID.String(),
+				Name:        policy.Body.Policy.Name,
+				Description: policy.Body.Policy.Description,
+				Type:        policy.Body.PolicyType,
+				AttachedTo:  attachedTeamsByPolicyID[*policy.ID],
+			}
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	fmt.Println("")
 	w := ansiterm.NewTabWriter(os.Stdout, 0, 0, 4, ' ', 0)
 	fmt.Fprintf(w, "%s\t%s\t%s\n", ui.BoldString("Policy Name"), ui.BoldString("Type"), ui.BoldString("Attached To"))
@@ -440,6 +536,24 @@ func viewPolicyCmd(ctx *cli.Context) error {
 	policy := policies[0]
 	p := policy.Body.Policy
 
+	if structuredOutput(ctx) {
+		out := policyOutput{
+			ID:          policy.ID.String(),
+			Name:        p.Name,
+			Description: p.Description,
+			Type:        policy.Body.PolicyType,
+			Statements:  make([]statementOutput, len(p.Statements)),
+		}
+		for i, stmt := range p.Statements {
+			out.Statements[i] = statementOutput{
+				Effect:   stmt.Effect.String(),
+				Action:   stmt.Action.ShortString(),
+				Resource: stmt.Resource,
+			}
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	w := tabwriter.NewWriter(os.Stdout, 2, 0, 1, ' ', 0)
 
 	fmt.Fprintf(w, "%s\t%s\n", ui.BoldString("Name:"), p.Name)
@@ -0,0 +1,645 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/juju/ansiterm"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+	"github.com/manifoldco/torus-cli/prompts"
+	"github.com/manifoldco/torus-cli/ui"
+	"github.com/manifoldco/torus-cli/validate"
+)
+
+// userPolicyType is the type of policies created by users, as opposed to the
+// system policies every org is created with. Only user policies are managed
+// by policy files.
+const userPolicyType = "user"
+
+// policyDocument is the declarative form of an org's user policies, and the
+// teams and machine roles they're attached to.
+type policyDocument struct {
+	Policies []policyDefinition `json:"policies"`
+}
+
+// policyDefinition is a single policy of a policy document. Its type is only
+// set for system policies, which are exported but can't be applied.
+type policyDefinition struct {
+	Name        string                `json:"name"`
+	Type        string                `json:"type,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Statements  []statementDefinition `json:"statements"`
+	Teams       []string              `json:"teams,omitempty"`
+}
+
+// statementDefinition is a single statement of a policy definition. Actions
+// are given in crudl form, as they are to allow and deny.
+type statementDefinition struct {
+	Effect string `json:"effect"`
+	Action string `json:"action"`
+	Path   string `json:"path"`
+}
+
+// readPolicyDocument reads a policy document from a JSON or YAML file. The
+// format is detected from the file's extension, and is YAML by default.
+// Unknown fields are an error, so typos don't go unnoticed.
+func readPolicyDocument(filename string) (*policyDocument, error) {
+	f, err := openFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &policyDocument{}
+	if strings.ToLower(filepath.Ext(filename)) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(doc)
+	} else {
+		err = yaml.UnmarshalStrict(data, doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// policyResource returns the resource for a statement's path. The ${org}
+// variable is replaced by the org's name, and paths must be within the org.
+func policyResource(orgName, path string) (string, error) {
+	pe, secret, err := parseRawPath(strings.Replace(path, "${org}", orgName, -1))
+	if err != nil {
+		return "", fmt.Errorf("Invalid path %s: %s", path, err)
+	}
+
+	if pe.Org.String() != orgName {
+		return "", fmt.Errorf("Path %s is not within the %s org", path, orgName)
+	}
+
+	return pe.String() + "/" + *secret, nil
+}
+
+// parseStatementAction parses crudl actions, ignoring any dashes so the
+// short form displayed by policies view is also accepted.
+func parseStatementAction(raw string) (primitive.PolicyAction, error) {
+	action, err := parseAction(strings.Replace(raw, "-", "", -1))
+	if err != nil {
+		return action, err
+	}
+	if action == 0 {
+		return action, errors.New("At least one action is required")
+	}
+
+	return action, nil
+}
+
+// desiredPolicy returns the policy described by the definition.
+func desiredPolicy(org *envelope.Org, def *policyDefinition) (*primitive.Policy, error) {
+	if err := validate.PolicyName(def.Name); err != nil {
+		return nil, err
+	}
+	if err := validate.Description(def.Description, "policy"); err != nil {
+		return nil, err
+	}
+	if len(def.Statements) == 0 {
+		return nil, errors.New("At least one statement is required")
+	}
+
+	policy := &primitive.Policy{
+		PolicyType: userPolicyType,
+		OrgID:      org.ID,
+	}
+	policy.Policy.Name = def.Name
+	policy.Policy.Description = def.Description
+
+	for _, s := range def.Statements {
+		var effect primitive.PolicyEffect
+		switch s.Effect {
+		case "allow":
+			effect = primitive.PolicyEffectAllow
+		case "deny":
+			effect = primitive.PolicyEffectDeny
+		default:
+			return nil, fmt.Errorf("Unknown effect %q, expected allow or deny", s.Effect)
+		}
+
+		action, err := parseStatementAction(s.Action)
+		if err != nil {
+			return nil, err
+		}
+
+		resource, err := policyResource(org.Body.Name, s.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		policy.Policy.Statements = append(policy.Policy.Statements, primitive.PolicyStatement{
+			Effect:   effect,
+			Action:   action,
+			Resource: resource,
+		})
+	}
+
+	return policy, nil
+}
+
+// statementKeys returns a sorted string form of the statements, for
+// comparison. Statements are unordered, as deny always overrides allow.
+func statementKeys(statements []primitive.PolicyStatement) []string {
+	keys := make([]string, len(statements))
+	for i, s := range statements {
+		keys[i] = s.Effect.String() + " " + s.Action.ShortString() + " " + s.Resource
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func samePolicy(a *primitive.Policy, b *primitive.Policy) bool {
+	if a.Policy.Description != b.Policy.Description {
+		return false
+	}
+
+	ak := statementKeys(a.Policy.Statements)
+	bk := statementKeys(b.Policy.Statements)
+	if len(ak) != len(bk) {
+		return false
+	}
+	for i := range ak {
+		if ak[i] != bk[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type policyChangeKind int
+
+const (
+	policyCreate policyChangeKind = iota
+	policyUpdate
+	policyDelete
+	policyAttach
+	policyDetach
+)
+
+// policyChange is a single change needed to make an org's policies match a
+// policy document.
+//
+// An update replaces the policy, and attaches the replacement to Teams, so
+// it isn't followed by attach or detach changes.
+type policyChange struct {
+	Kind   policyChangeKind
+	Policy string
+	Team   string
+	Teams  []string
+
+	desired      *primitive.Policy
+	current      *envelope.Policy
+	teamID       *identity.ID
+	teamIDs      []*identity.ID
+	currentTeams []*identity.ID
+	attachment   *identity.ID
+}
+
+func (c *policyChange) String() string {
+	switch c.Kind {
+	case policyCreate:
+		return "create policy " + c.Policy
+	case policyUpdate:
+		if len(c.Teams) == 0 {
+			return "update policy " + c.Policy
+		}
+		return "update policy " + c.Policy + ", attached to " + strings.Join(c.Teams, ", ")
+	case policyDelete:
+		return "delete policy " + c.Policy
+	case policyAttach:
+		return "attach policy " + c.Policy + " to " + c.Team
+	default:
+		return "detach policy " + c.Policy + " from " + c.Team
+	}
+}
+
+// planPolicies returns the changes needed to make the org's user policies,
+// and their attachments, match the document. Changes are grouped by policy,
+// in order of name.
+//
+// Policies are replaced when updated, and the replacement is attached to the
+// policy's teams as part of the update. System policies are never changed.
+func planPolicies(org *envelope.Org, doc *policyDocument, current []envelope.Policy,
+	attachments []envelope.PolicyAttachment, teams []envelope.Team) ([]policyChange, error) {
+
+	teamsByName := make(map[string]*envelope.Team, len(teams))
+	teamNames := make(map[identity.ID]string, len(teams))
+	for i, t := range teams {
+		teamsByName[t.Body.Name] = &teams[i]
+		teamNames[*t.ID] = t.Body.Name
+	}
+
+	currentByName := make(map[string]*envelope.Policy, len(current))
+	for i, p := range current {
+		currentByName[p.Body.Policy.Name] = &current[i]
+	}
+
+	attachedByPolicy := make(map[identity.ID]map[string]*identity.ID)
+	for _, a := range attachments {
+		id := *a.Body.PolicyID
+		if attachedByPolicy[id] == nil {
+			attachedByPolicy[id] = make(map[string]*identity.ID)
+		}
+		attachedByPolicy[id][teamNames[*a.Body.OwnerID]] = a.ID
+	}
+
+	names := []string{}
+	desired := make(map[string]*primitive.Policy, len(doc.Policies))
+	desiredTeams := make(map[string][]string, len(doc.Policies))
+	for i := range doc.Policies {
+		def := &doc.Policies[i]
+		if _, ok := desired[def.Name]; ok {
+			return nil, fmt.Errorf("Policy %s is defined more than once", def.Name)
+		}
+		if def.Type != "" && def.Type != userPolicyType {
+			return nil, fmt.Errorf("Policy %s is a %s policy, which cannot be changed", def.Name, def.Type)
+		}
+		if p, ok := currentByName[def.Name]; ok && p.Body.PolicyType != userPolicyType {
+			return nil, fmt.Errorf("Policy %s is a %s policy, which cannot be changed", def.Name, p.Body.PolicyType)
+		}
+
+		policy, err := desiredPolicy(org, def)
+		if err != nil {
+			return nil, fmt.Errorf("Policy %s: %s", def.Name, err)
+		}
+
+		for _, team := range def.Teams {
+			if _, ok := teamsByName[team]; !ok {
+				return nil, fmt.Errorf("Policy %s: team or machine role %s not found", def.Name, team)
+			}
+		}
+
+		teams := append([]string{}, def.Teams...)
+		sort.Strings(teams)
+
+		names = append(names, def.Name)
+		desired[def.Name] = policy
+		desiredTeams[def.Name] = teams
+	}
+
+	for name, p := range currentByName {
+		if _, ok := desired[name]; !ok && p.Body.PolicyType == userPolicyType {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []policyChange{}
+	for _, name := range names {
+		want, cur := desired[name], currentByName[name]
+
+		var attached map[string]*identity.ID
+		switch {
+		case cur == nil:
+			changes = append(changes, policyChange{Kind: policyCreate, Policy: name, desired: want})
+		case want == nil:
+			changes = append(changes, policyChange{Kind: policyDelete, Policy: name, current: cur})
+			continue
+		case !samePolicy(want, cur.Body):
+			change := policyChange{
+				Kind: policyUpdate, Policy: name, Teams: desiredTeams[name], desired: want, current: cur,
+			}
+			for _, team := range desiredTeams[name] {
+				change.teamIDs = append(change.teamIDs, teamsByName[team].ID)
+			}
+			currentTeams := []string{}
+			for team := range attachedByPolicy[*cur.ID] {
+				currentTeams = append(currentTeams, team)
+			}
+			sort.Strings(currentTeams)
+			for _, team := range currentTeams {
+				if t, ok := teamsByName[team]; ok {
+					change.currentTeams = append(change.currentTeams, t.ID)
+				}
+			}
+			changes = append(changes, change)
+			continue
+		default:
+			attached = attachedByPolicy[*cur.ID]
+		}
+
+		for _, team := range desiredTeams[name] {
+			if _, ok := attached[team]; ok {
+				continue
+			}
+			changes = append(changes, policyChange{
+				Kind: policyAttach, Policy: name, Team: team, current: cur, teamID: teamsByName[team].ID,
+			})
+		}
+
+		detach := []string{}
+		for team := range attached {
+			if !containsString(desiredTeams[name], team) {
+				detach = append(detach, team)
+			}
+		}
+		sort.Strings(detach)
+
+		for _, team := range detach {
+			changes = append(changes, policyChange{
+				Kind: policyDetach, Policy: name, Team: team, attachment: attached[team],
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writePolicyChanges writes each change on its own line, prefixed by + for
+// creations and attachments, - for deletions and detachments, and ~ for
+// updates.
+func writePolicyChanges(w io.Writer, changes []policyChange) {
+	tw := ansiterm.NewTabWriter(w, 2, 0, 2, ' ', 0)
+
+	for _, c := range changes {
+		var sign string
+		switch c.Kind {
+		case policyCreate, policyAttach:
+			sign = ui.ColorString(ui.Green, "+")
+		case policyDelete, policyDetach:
+			sign = ui.ColorString(ui.Red, "-")
+		case policyUpdate:
+			sign = ui.ColorString(ui.Yellow, "~")
+		}
+
+		fmt.Fprintf(tw, "%s %s\n", sign, c.String())
+	}
+
+	tw.Flush()
+}
+
+// policyFilePlan reads the policy file given in ctx, and returns the changes
+// needed to make the org match it.
+func policyFilePlan(c context.Context, client *api.Client, ctx *cli.Context) (*envelope.Org, []policyChange, error) {
+	filename := ctx.String("file")
+	doc, err := readPolicyDocument(filename)
+	if err != nil {
+		return nil, nil, errs.NewErrorExitError("Could not read policies from "+filename, err)
+	}
+
+	org, _, _, err := selectOrg(c, client, ctx.String("org"), false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	policies, err := client.Policies.List(c, org.ID, "")
+	if err != nil {
+		return nil, nil, errs.NewErrorExitError(policyListFailed, err)
+	}
+
+	attachments, err := client.Policies.AttachmentsList(c, org.ID, nil, nil)
+	if err != nil {
+		return nil, nil, errs.NewErrorExitError(policyListFailed, err)
+	}
+
+	teams, err := client.Teams.GetByOrg(c, org.ID)
+	if err != nil {
+		return nil, nil, errs.NewErrorExitError("Could not retrieve teams", err)
+	}
+
+	changes, err := planPolicies(org, doc, policies, attachments, teams)
+	if err != nil {
+		return nil, nil, errs.NewErrorExitError("Invalid policies in "+filename, err)
+	}
+
+	return org, changes, nil
+}
+
+func planPoliciesCmd(ctx *cli.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, changes, err := policyFilePlan(c, client, ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("No changes, the policies of the %s org match %s.\n", org.Body.Name, ctx.String("file"))
+		return nil
+	}
+
+	fmt.Printf("The following changes will be made to the %s org:\n\n", org.Body.Name)
+	writePolicyChanges(os.Stdout, changes)
+	fmt.Printf("\n(%d) change%s planned\n", len(changes), plural(len(changes)))
+
+	return nil
+}
+
+func applyPoliciesCmd(ctx *cli.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, changes, err := policyFilePlan(c, client, ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("No changes, the policies of the %s org match %s.\n", org.Body.Name, ctx.String("file"))
+		return nil
+	}
+
+	fmt.Printf("The following changes will be made to the %s org:\n\n", org.Body.Name)
+	writePolicyChanges(os.Stdout, changes)
+	fmt.Println()
+
+	if !ctx.Bool("yes") {
+		preamble := fmt.Sprintf("You are about to make %d change%s to the policies of the %s org.",
+			len(changes), plural(len(changes)), org.Body.Name)
+		success, err := prompts.Confirm(nil, &preamble, true, false)
+		if err != nil {
+			return errs.NewErrorExitError("Failed to retrieve confirmation", err)
+		}
+		if !success {
+			return errs.ErrAbort
+		}
+	}
+
+	if err := applyPolicyChanges(c, client.Policies, org, changes); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nThe policies of the %s org now match %s.\n", org.Body.Name, ctx.String("file"))
+	return nil
+}
+
+// policyClient is the part of the policies client used to apply changes.
+type policyClient interface {
+	Create(ctx context.Context, policy *primitive.Policy) (*envelope.Policy, error)
+	Delete(ctx context.Context, policyID *identity.ID) error
+	Attach(ctx context.Context, org, policy, team *identity.ID) error
+	Detach(ctx context.Context, attachmentID *identity.ID) error
+}
+
+// applyPolicyChanges makes each of the changes in order, stopping at the
+// first failure.
+func applyPolicyChanges(c context.Context, client policyClient, org *envelope.Org, changes []policyChange) error {
+	created := make(map[string]*identity.ID)
+
+	for _, change := range changes {
+		var err error
+		switch change.Kind {
+		case policyCreate:
+			var res *envelope.Policy
+			res, err = client.Create(c, change.desired)
+			if err == nil {
+				created[change.Policy] = res.ID
+			}
+		case policyUpdate:
+			err = replacePolicy(c, client, org, &change)
+		case policyDelete:
+			err = client.Delete(c, change.current.ID)
+		case policyAttach:
+			policyID, ok := created[change.Policy]
+			if !ok {
+				policyID = change.current.ID
+			}
+			err = client.Attach(c, org.ID, policyID, change.teamID)
+		case policyDetach:
+			err = client.Detach(c, change.attachment)
+		}
+
+		if err != nil {
+			return errs.NewErrorExitError("Could not "+change.String(), err)
+		}
+
+		fmt.Printf("Done: %s\n", change.String())
+	}
+
+	return nil
+}
+
+// replacingPolicyName returns the temporary name of a policy's replacement,
+// used while the policy still exists, as policy names are unique.
+func replacingPolicyName(name string) string {
+	const suffix = "-replacing"
+	if len(name) > 64-len(suffix) {
+		name = name[:64-len(suffix)]
+	}
+
+	return name + suffix
+}
+
+// replacePolicy replaces the current policy of an update with the desired
+// one, so the policy's teams are never left without it:
+//
+//  1. a temporary copy of the desired policy is created, and attached
+//  2. the current policy is deleted, along with its attachments
+//  3. the desired policy is created under its own name, and attached
+//  4. the temporary copy is deleted
+//
+// If any step but the last fails, the changes made so far are rolled back,
+// recreating the current policy if it was deleted.
+func replacePolicy(c context.Context, client policyClient, org *envelope.Org, change *policyChange) error {
+	tmp := *change.desired
+	tmp.Policy.Name = replacingPolicyName(change.Policy)
+
+	tmpPolicy, err := createAttachedPolicy(c, client, org, &tmp, change.teamIDs)
+	if err != nil {
+		return rollbackPolicy(err, nil)
+	}
+
+	// Deleting a policy also deletes its attachments.
+	if err := client.Delete(c, change.current.ID); err != nil {
+		return rollbackPolicy(err, client.Delete(c, tmpPolicy.ID))
+	}
+
+	if _, err := createAttachedPolicy(c, client, org, change.desired, change.teamIDs); err != nil {
+		var rerr error
+		if _, rerr = createAttachedPolicy(c, client, org, change.current.Body, change.currentTeams); rerr == nil {
+			rerr = client.Delete(c, tmpPolicy.ID)
+		}
+		return rollbackPolicy(err, rerr)
+	}
+
+	if err := client.Delete(c, tmpPolicy.ID); err != nil {
+		return fmt.Errorf("%s; the temporary policy %s is still attached, and must be deleted",
+			err, tmp.Policy.Name)
+	}
+
+	return nil
+}
+
+// createAttachedPolicy creates the policy, and attaches it to each of the
+// teams. If an attachment fails, the policy is deleted.
+func createAttachedPolicy(c context.Context, client policyClient, org *envelope.Org,
+	policy *primitive.Policy, teams []*identity.ID) (*envelope.Policy, error) {
+
+	res, err := client.Create(c, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, team := range teams {
+		if err := client.Attach(c, org.ID, res.ID, team); err != nil {
+			if derr := client.Delete(c, res.ID); derr != nil {
+				return nil, fmt.Errorf("%s; deleting policy %s also failed: %s", err, policy.Policy.Name, derr)
+			}
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// rollbackPolicy returns the error which caused a rollback, noting whether
+// the rollback failed too.
+func rollbackPolicy(err, rerr error) error {
+	if rerr != nil {
+		return fmt.Errorf("%s; rolling back also failed: %s", err, rerr)
+	}
+
+	return fmt.Errorf("%s; the change has been rolled back", err)
+}
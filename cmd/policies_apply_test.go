@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func mutableID(t *testing.T, body identity.Mutable) *identity.ID {
+	id, err := identity.NewMutable(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &id
+}
+
+type policyFixture struct {
+	org         *envelope.Org
+	teams       []envelope.Team
+	policies    []envelope.Policy
+	attachments []envelope.PolicyAttachment
+}
+
+func (f *policyFixture) team(t *testing.T, name string) {
+	f.teams = append(f.teams, envelope.Team{
+		ID:   mutableID(t, &primitive.Team{}),
+		Body: &primitive.Team{Name: name, OrgID: f.org.ID},
+	})
+}
+
+func (f *policyFixture) policy(t *testing.T, typ, name, description string, stmts []primitive.PolicyStatement, teams ...string) {
+	body := &primitive.Policy{PolicyType: typ, OrgID: f.org.ID}
+	body.Policy.Name = name
+	body.Policy.Description = description
+	body.Policy.Statements = stmts
+
+	p := envelope.Policy{ID: mutableID(t, &primitive.Policy{}), Body: body}
+	f.policies = append(f.policies, p)
+
+	for _, team := range teams {
+		for _, tm := range f.teams {
+			if tm.Body.Name == team {
+				f.attachments = append(f.attachments, envelope.PolicyAttachment{
+					ID:   mutableID(t, &primitive.PolicyAttachment{}),
+					Body: &primitive.PolicyAttachment{OwnerID: tm.ID, PolicyID: p.ID, OrgID: f.org.ID},
+				})
+			}
+		}
+	}
+}
+
+func newPolicyFixture(t *testing.T) *policyFixture {
+	orgBody := &primitive.Org{Name: "o"}
+	f := &policyFixture{org: &envelope.Org{ID: mutableID(t, orgBody), Body: orgBody}}
+
+	for _, name := range []string{"member", "admin", "ops", "ci"} {
+		f.team(t, name)
+	}
+
+	f.policy(t, "system", "default-admin", "", []primitive.PolicyStatement{
+		{Effect: primitive.PolicyEffectAllow, Action: primitive.PolicyActionRead, Resource: "/${org}/*"},
+	}, "admin")
+	f.policy(t, "user", "read-prod", "Read production", []primitive.PolicyStatement{
+		{Effect: primitive.PolicyEffectAllow, Action: primitive.PolicyActionRead | primitive.PolicyActionList,
+			Resource: "/o/p/prod/*/*/*/*"},
+	}, "ops", "admin")
+	f.policy(t, "user", "generated-allow-1", "", []primitive.PolicyStatement{
+		{Effect: primitive.PolicyEffectAllow, Action: primitive.PolicyActionRead, Resource: "/o/p/dev/*/*/*/*"},
+	}, "member")
+
+	return f
+}
+
+func planStrings(changes []policyChange) []string {
+	out := make([]string, len(changes))
+	for i, c := range changes {
+		out[i] = c.String()
+	}
+	return out
+}
+
+func TestPlanPolicies(t *testing.T) {
+	f := newPolicyFixture(t)
+
+	t.Run("no changes", func(t *testing.T) {
+		doc := &policyDocument{Policies: []policyDefinition{
+			{Name: "read-prod", Description: "Read production", Teams: []string{"admin", "ops"},
+				Statements: []statementDefinition{{Effect: "allow", Action: "-r--l", Path: "/${org}/p/prod/**"}}},
+			{Name: "generated-allow-1", Teams: []string{"member"},
+				Statements: []statementDefinition{{Effect: "allow", Action: "r", Path: "/o/p/dev/*/*/*/*"}}},
+		}}
+
+		changes, err := planPolicies(f.org, doc, f.policies, f.attachments, f.teams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 0 {
+			t.Errorf("expected no changes, got %q", planStrings(changes))
+		}
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		doc := &policyDocument{Policies: []policyDefinition{
+			{Name: "read-prod", Description: "Read production", Teams: []string{"ci", "ops"},
+				Statements: []statementDefinition{{Effect: "allow", Action: "rl", Path: "/o/p/prod/**"}}},
+			{Name: "deny-prod-db", Teams: []string{"ci"},
+				Statements: []statementDefinition{{Effect: "deny", Action: "crudl", Path: "/o/p/prod/db/db_url"}}},
+		}}
+
+		changes, err := planPolicies(f.org, doc, f.policies, f.attachments, f.teams)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{
+			"create policy deny-prod-db",
+			"attach policy deny-prod-db to ci",
+			"delete policy generated-allow-1",
+			"attach policy read-prod to ci",
+			"detach policy read-prod from admin",
+		}
+		if got := planStrings(changes); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("expected changes:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+		}
+		if r := changes[0].desired.Policy.Statements[0].Resource; r != "/o/p/prod/db/*/*/db_url" {
+			t.Errorf("expected a normalised resource, got %s", r)
+		}
+	})
+
+	t.Run("updated policies are attached as part of the update", func(t *testing.T) {
+		doc := &policyDocument{Policies: []policyDefinition{
+			{Name: "read-prod", Description: "Read production", Teams: []string{"admin", "ops"},
+				Statements: []statementDefinition{{Effect: "allow", Action: "r", Path: "/o/p/prod/**"}}},
+			{Name: "generated-allow-1", Teams: []string{"member"},
+				Statements: []statementDefinition{{Effect: "allow", Action: "r", Path: "/o/p/dev/**"}}},
+		}}
+
+		changes, err := planPolicies(f.org, doc, f.policies, f.attachments, f.teams)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{"update policy read-prod, attached to admin, ops"}
+		if got := planStrings(changes); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("expected changes:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		stmts := []statementDefinition{{Effect: "allow", Action: "r", Path: "/o/p/prod/**"}}
+		tcs := map[string][]policyDefinition{
+			"duplicate":    {{Name: "a", Statements: stmts}, {Name: "a", Statements: stmts}},
+			"system":       {{Name: "default-admin", Statements: stmts}},
+			"unknown team": {{Name: "a", Statements: stmts, Teams: []string{"nobody"}}},
+			"no statement": {{Name: "a"}},
+			"bad effect":   {{Name: "a", Statements: []statementDefinition{{Effect: "permit", Action: "r", Path: "/o/p/**"}}}},
+			"bad action":   {{Name: "a", Statements: []statementDefinition{{Effect: "allow", Action: "x", Path: "/o/p/**"}}}},
+			"other org":    {{Name: "a", Statements: []statementDefinition{{Effect: "allow", Action: "r", Path: "/x/p/**"}}}},
+		}
+
+		for name, defs := range tcs {
+			_, err := planPolicies(f.org, &policyDocument{Policies: defs}, f.policies, f.attachments, f.teams)
+			if err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
+}
+
+// fakePolicyClient holds policies and their attachments in memory. Policy
+// names are unique, and the first create of the policy named failCreate
+// fails once the policy named failAfterDelete has been deleted.
+type fakePolicyClient struct {
+	t        *testing.T
+	policies map[identity.ID]*primitive.Policy
+	teams    map[identity.ID][]identity.ID
+	deleted  map[string]bool
+
+	failCreate      string
+	failAfterDelete string
+}
+
+func newFakePolicyClient(t *testing.T, f *policyFixture) *fakePolicyClient {
+	pc := &fakePolicyClient{
+		t:        t,
+		policies: make(map[identity.ID]*primitive.Policy),
+		teams:    make(map[identity.ID][]identity.ID),
+		deleted:  make(map[string]bool),
+	}
+	for _, p := range f.policies {
+		pc.policies[*p.ID] = p.Body
+	}
+	for _, a := range f.attachments {
+		pc.teams[*a.Body.PolicyID] = append(pc.teams[*a.Body.PolicyID], *a.Body.OwnerID)
+	}
+
+	return pc
+}
+
+func (pc *fakePolicyClient) Create(ctx context.Context, policy *primitive.Policy) (*envelope.Policy, error) {
+	name := policy.Policy.Name
+	if name == pc.failCreate && pc.deleted[pc.failAfterDelete] {
+		pc.failCreate = ""
+		return nil, errors.New("create failed")
+	}
+	for _, p := range pc.policies {
+		if p.Policy.Name == name {
+			return nil, errors.New("policy " + name + " already exists")
+		}
+	}
+
+	body := *policy
+	id := mutableID(pc.t, &primitive.Policy{})
+	pc.policies[*id] = &body
+	return &envelope.Policy{ID: id, Body: &body}, nil
+}
+
+func (pc *fakePolicyClient) Delete(ctx context.Context, policyID *identity.ID) error {
+	p, ok := pc.policies[*policyID]
+	if !ok {
+		return errors.New("policy not found")
+	}
+
+	pc.deleted[p.Policy.Name] = true
+	delete(pc.policies, *policyID)
+	delete(pc.teams, *policyID)
+	return nil
+}
+
+func (pc *fakePolicyClient) Attach(ctx context.Context, org, policy, team *identity.ID) error {
+	pc.teams[*policy] = append(pc.teams[*policy], *team)
+	return nil
+}
+
+func (pc *fakePolicyClient) Detach(ctx context.Context, attachmentID *identity.ID) error {
+	return errors.New("detach is not expected")
+}
+
+// state returns each policy's name, statements and teams.
+func (pc *fakePolicyClient) state(f *policyFixture) []string {
+	teamNames := make(map[identity.ID]string)
+	for _, t := range f.teams {
+		teamNames[*t.ID] = t.Body.Name
+	}
+
+	out := []string{}
+	for id, p := range pc.policies {
+		teams := []string{}
+		for _, t := range pc.teams[id] {
+			teams = append(teams, teamNames[t])
+		}
+		sort.Strings(teams)
+
+		out = append(out, p.Policy.Name+" "+strings.Join(statementKeys(p.Policy.Statements), ",")+
+			" "+strings.Join(teams, ","))
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+func TestApplyPolicyChanges(t *testing.T) {
+	f := newPolicyFixture(t)
+	doc := &policyDocument{Policies: []policyDefinition{
+		{Name: "read-prod", Description: "Read production", Teams: []string{"admin", "ci"},
+			Statements: []statementDefinition{{Effect: "allow", Action: "r", Path: "/o/p/prod/**"}}},
+		{Name: "generated-allow-1", Teams: []string{"member"},
+			Statements: []statementDefinition{{Effect: "allow", Action: "r", Path: "/o/p/dev/*/*/*/*"}}},
+	}}
+
+	changes, err := planPolicies(f.org, doc, f.policies, f.attachments, f.teams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("update", func(t *testing.T) {
+		pc := newFakePolicyClient(t, f)
+		if err := applyPolicyChanges(context.Background(), pc, f.org, changes); err != nil {
+			t.Fatalf("applyPolicyChanges() expected no errors, got %s", err)
+		}
+
+		want := []string{
+			"default-admin allow -r--- /${org}/* admin",
+			"generated-allow-1 allow -r--- /o/p/dev/*/*/*/* member",
+			"read-prod allow -r--- /o/p/prod/*/*/*/* admin,ci",
+		}
+		if got := pc.state(f); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("expected policies:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+		}
+	})
+
+	t.Run("create fails after the delete", func(t *testing.T) {
+		pc := newFakePolicyClient(t, f)
+		before := pc.state(f)
+
+		pc.failCreate = "read-prod"
+		pc.failAfterDelete = "read-prod"
+		err := applyPolicyChanges(context.Background(), pc, f.org, changes)
+		if err == nil || !strings.Contains(err.Error(), "rolled back") {
+			t.Fatalf("applyPolicyChanges() expected the change to be rolled back, got %v", err)
+		}
+
+		if !pc.deleted["read-prod"] {
+			t.Error("applyPolicyChanges() expected the policy to have been deleted before the create")
+		}
+		if got := pc.state(f); strings.Join(got, "\n") != strings.Join(before, "\n") {
+			t.Errorf("expected policies:\n%s\ngot:\n%s", strings.Join(before, "\n"), strings.Join(got, "\n"))
+		}
+	})
+}
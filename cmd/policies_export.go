@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// exportPolicyDocument returns the policy document describing the org's
+// policies, and their attachments. System policies are only included if
+// system is set.
+//
+// The document is normalised so documents can be compared: policies are
+// ordered by name, statements by path, effect and action, and teams by name.
+// The org's name is replaced by the ${org} variable in paths.
+func exportPolicyDocument(org *envelope.Org, policies []envelope.Policy,
+	attachments []envelope.PolicyAttachment, teams []envelope.Team, system bool) *policyDocument {
+
+	teamNames := make(map[identity.ID]string, len(teams))
+	for _, t := range teams {
+		teamNames[*t.ID] = t.Body.Name
+	}
+
+	attached := make(map[identity.ID][]string)
+	for _, a := range attachments {
+		if name, ok := teamNames[*a.Body.OwnerID]; ok {
+			attached[*a.Body.PolicyID] = append(attached[*a.Body.PolicyID], name)
+		}
+	}
+
+	doc := &policyDocument{Policies: []policyDefinition{}}
+	for _, p := range policies {
+		if p.Body.PolicyType != userPolicyType && !system {
+			continue
+		}
+
+		def := policyDefinition{
+			Name:        p.Body.Policy.Name,
+			Description: p.Body.Policy.Description,
+			Statements:  []statementDefinition{},
+			Teams:       attached[*p.ID],
+		}
+		if p.Body.PolicyType != userPolicyType {
+			def.Type = p.Body.PolicyType
+		}
+
+		for _, s := range p.Body.Policy.Statements {
+			def.Statements = append(def.Statements, statementDefinition{
+				Effect: s.Effect.String(),
+				Action: s.Action.ShortString(),
+				Path:   exportPath(org.Body.Name, s.Resource),
+			})
+		}
+
+		sort.Slice(def.Statements, func(i, j int) bool {
+			a, b := def.Statements[i], def.Statements[j]
+			if a.Path != b.Path {
+				return a.Path < b.Path
+			}
+			if a.Effect != b.Effect {
+				return a.Effect < b.Effect
+			}
+			return a.Action < b.Action
+		})
+		sort.Strings(def.Teams)
+
+		doc.Policies = append(doc.Policies, def)
+	}
+
+	sort.Slice(doc.Policies, func(i, j int) bool {
+		return doc.Policies[i].Name < doc.Policies[j].Name
+	})
+
+	return doc
+}
+
+// exportPath replaces the org's name at the start of a resource with the
+// ${org} variable.
+func exportPath(orgName, resource string) string {
+	prefix := "/" + orgName
+	if resource == prefix || strings.HasPrefix(resource, prefix+"/") {
+		return "/${org}" + strings.TrimPrefix(resource, prefix)
+	}
+
+	return resource
+}
+
+func exportPoliciesCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	filename := ""
+	if len(args) > 0 {
+		if len(args) != 1 {
+			return errs.NewUsageExitError("Only one argument can be supplied.", ctx)
+		}
+
+		filename = args[0]
+	}
+
+	format := ctx.String("format")
+	if format == "" {
+		format = "yaml"
+		if strings.ToLower(filepath.Ext(filename)) == ".json" {
+			format = "json"
+		}
+	}
+	if format != "yaml" && format != "json" {
+		return errs.NewUsageExitError("Invalid format provided: "+format, ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, _, _, err := selectOrg(c, client, ctx.String("org"), false)
+	if err != nil {
+		return err
+	}
+
+	policies, err := client.Policies.List(c, org.ID, "")
+	if err != nil {
+		return errs.NewErrorExitError(policyListFailed, err)
+	}
+
+	attachments, err := client.Policies.AttachmentsList(c, org.ID, nil, nil)
+	if err != nil {
+		return errs.NewErrorExitError(policyListFailed, err)
+	}
+
+	teams, err := client.Teams.GetByOrg(c, org.ID)
+	if err != nil {
+		return errs.NewErrorExitError("Could not retrieve teams", err)
+	}
+
+	doc := exportPolicyDocument(org, policies, attachments, teams, ctx.Bool("system"))
+
+	var w io.Writer = os.Stdout
+	if filename != "" {
+		fd, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return errs.NewErrorExitError("Could not write to given filepath", err)
+		}
+		defer fd.Close()
+
+		w = fd
+	}
+
+	return writeStructured(w, format, doc)
+}
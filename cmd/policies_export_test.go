@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestExportPolicyDocument(t *testing.T) {
+	f := newPolicyFixture(t)
+	f.policy(t, "user", "deploy", "", []primitive.PolicyStatement{
+		{Effect: primitive.PolicyEffectDeny, Action: primitive.PolicyActionDelete, Resource: "/o/p/prod/*/*/*/*"},
+		{Effect: primitive.PolicyEffectAllow, Action: primitive.PolicyActionRead, Resource: "/o/p/prod/*/*/*/*"},
+		{Effect: primitive.PolicyEffectAllow, Action: primitive.PolicyActionList, Resource: "/o/p/dev/*/*/*/*"},
+	}, "ops", "ci")
+
+	t.Run("normalised", func(t *testing.T) {
+		doc := exportPolicyDocument(f.org, f.policies, f.attachments, f.teams, false)
+
+		buf := &bytes.Buffer{}
+		if err := writeStructured(buf, "yaml", doc); err != nil {
+			t.Fatal(err)
+		}
+
+		want := `policies:
+- name: deploy
+  statements:
+  - effect: allow
+    action: '----l'
+    path: /${org}/p/dev/*/*/*/*
+  - effect: allow
+    action: -r---
+    path: /${org}/p/prod/*/*/*/*
+  - effect: deny
+    action: '---d-'
+    path: /${org}/p/prod/*/*/*/*
+  teams:
+  - ci
+  - ops
+- name: generated-allow-1
+  statements:
+  - effect: allow
+    action: -r---
+    path: /${org}/p/dev/*/*/*/*
+  teams:
+  - member
+- name: read-prod
+  description: Read production
+  statements:
+  - effect: allow
+    action: -r--l
+    path: /${org}/p/prod/*/*/*/*
+  teams:
+  - admin
+  - ops
+`
+		if buf.String() != want {
+			t.Errorf("expected:\n%s\ngot:\n%s", want, buf.String())
+		}
+	})
+
+	t.Run("applies without changes", func(t *testing.T) {
+		doc := exportPolicyDocument(f.org, f.policies, f.attachments, f.teams, false)
+
+		changes, err := planPolicies(f.org, doc, f.policies, f.attachments, f.teams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 0 {
+			t.Errorf("expected no changes, got %q", planStrings(changes))
+		}
+	})
+
+	t.Run("system policies", func(t *testing.T) {
+		doc := exportPolicyDocument(f.org, f.policies, f.attachments, f.teams, true)
+		if len(doc.Policies) != 4 {
+			t.Fatalf("expected 4 policies, got %d", len(doc.Policies))
+		}
+
+		p := doc.Policies[0]
+		if p.Name != "default-admin" || p.Type != "system" || p.Statements[0].Path != "/${org}/*" {
+			t.Errorf("expected the system default-admin policy, got %+v", p)
+		}
+	})
+}
+
+func TestExportPath(t *testing.T) {
+	tcs := map[string]string{
+		"/o/p/e/*/*/*/*": "/${org}/p/e/*/*/*/*",
+		"/o":             "/${org}",
+		"/org/*":         "/org/*",
+		"/${org}/*":      "/${org}/*",
+		"teams:*":        "teams:*",
+	}
+
+	for resource, want := range tcs {
+		if got := exportPath("o", resource); got != want {
+			t.Errorf("exportPath(%q) expected %q, got %q", resource, want, got)
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/juju/ansiterm"
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+// lintSeverity is how serious a lint finding is
+type lintSeverity int
+
+const (
+	lintInfo lintSeverity = iota
+	lintWarning
+	lintError
+)
+
+var lintSeverities = []string{"info", "warning", "error"}
+
+func (s lintSeverity) String() string {
+	return lintSeverities[s]
+}
+
+// parseLintSeverity returns the severity with the given name.
+func parseLintSeverity(name string) (lintSeverity, error) {
+	for i, s := range lintSeverities {
+		if s == name {
+			return lintSeverity(i), nil
+		}
+	}
+
+	return lintInfo, fmt.Errorf("Unknown severity %s, must be one of %s", name,
+		strings.Join(lintSeverities, ", "))
+}
+
+// The checks made by lintPolicies
+const (
+	lintShadowed  = "shadowed"
+	lintNoop      = "no-op"
+	lintDuplicate = "duplicate"
+	lintWriteEnvs = "write-all-envs"
+	lintDetached  = "unattached"
+)
+
+// writeActions are the actions which change secrets
+const writeActions = primitive.PolicyAction(primitive.PolicyActionCreate |
+	primitive.PolicyActionUpdate | primitive.PolicyActionDelete)
+
+// lintFinding is a problem found in a policy, or one of its statements.
+type lintFinding struct {
+	Severity  lintSeverity
+	Check     string
+	Policy    string
+	Statement *primitive.PolicyStatement
+	Message   string
+}
+
+// lintFindingOutput is the structured output of a lint finding
+type lintFindingOutput struct {
+	Severity  string           `json:"severity"`
+	Check     string           `json:"check"`
+	Policy    string           `json:"policy"`
+	Statement *statementOutput `json:"statement,omitempty"`
+	Message   string           `json:"message"`
+}
+
+// lintPolicies analyses the user policies of an org. System policies can't
+// be changed, so they aren't analysed.
+//
+// An allow statement is only reported as shadowed by a deny in the same
+// policy, or in a policy attached to every team its own policy is attached
+// to, as the allow still applies to any other team. It's only reported as a
+// no-op when covered by an allow in the same policy.
+//
+// Findings are ordered by severity, most serious first, then by policy name.
+func lintPolicies(org *envelope.Org, policies []envelope.Policy,
+	attachments []envelope.PolicyAttachment) []lintFinding {
+
+	teams := make(map[identity.ID][]identity.ID)
+	for _, a := range attachments {
+		teams[*a.Body.PolicyID] = append(teams[*a.Body.PolicyID], *a.Body.OwnerID)
+	}
+
+	vars := map[string]string{"org": org.Body.Name}
+
+	userPolicies := []envelope.Policy{}
+	for _, p := range policies {
+		if p.Body.PolicyType == userPolicyType {
+			userPolicies = append(userPolicies, p)
+		}
+	}
+
+	sort.SliceStable(userPolicies, func(i, j int) bool {
+		return userPolicies[i].Body.Policy.Name < userPolicies[j].Body.Policy.Name
+	})
+
+	findings := []lintFinding{}
+	add := func(severity lintSeverity, check string, p envelope.Policy, s *primitive.PolicyStatement,
+		format string, a ...interface{}) {
+
+		findings = append(findings, lintFinding{
+			Severity:  severity,
+			Check:     check,
+			Policy:    p.Body.Policy.Name,
+			Statement: s,
+			Message:   fmt.Sprintf(format, a...),
+		})
+	}
+
+	for i, p := range userPolicies {
+		if len(teams[*p.ID]) == 0 {
+			add(lintInfo, lintDetached, p, nil, "Policy is not attached to any team, so it has no effect")
+		}
+
+		for j := 0; j < i; j++ {
+			if sameStatements(userPolicies[j].Body, p.Body, vars) {
+				add(lintWarning, lintDuplicate, p, nil, "Policy has the same statements as %s",
+					userPolicies[j].Body.Policy.Name)
+				break
+			}
+		}
+
+		for k := range p.Body.Policy.Statements {
+			stmt := &p.Body.Policy.Statements[k]
+			if stmt.Effect != primitive.PolicyEffectAllow {
+				continue
+			}
+
+			resource := expandResource(stmt.Resource, vars)
+			if stmt.Action&writeActions != 0 && allEnvironments(resource) {
+				write := stmt.Action & writeActions
+				add(lintError, lintWriteEnvs, p, stmt, "Statement allows %s on every environment",
+					write.String())
+			}
+
+			deny, q := coveringStatement(userPolicies, teams, p, k, primitive.PolicyEffectDeny, vars)
+			if deny != nil {
+				add(lintWarning, lintShadowed, p, stmt, "Statement is shadowed by deny %s %s in %s",
+					deny.Action.ShortString(), deny.Resource, q.Body.Policy.Name)
+				continue
+			}
+
+			allow, q := coveringStatement(userPolicies, teams, p, k, primitive.PolicyEffectAllow, vars)
+			if allow != nil {
+				add(lintWarning, lintNoop, p, stmt, "Statement is already allowed by %s %s in %s",
+					allow.Action.ShortString(), allow.Resource, q.Body.Policy.Name)
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity > findings[j].Severity
+		}
+		return findings[i].Policy < findings[j].Policy
+	})
+
+	return findings
+}
+
+// coveringStatement returns a statement with the given effect, and the
+// policy it's in, which covers every action and resource of the k'th
+// statement of policy p.
+//
+// Deny statements are looked for in p, and in every policy attached to all
+// of p's teams. Allow statements are only looked for in p, as an allow in another
+// policy may be detached independently. When two allow statements cover each
+// other, only the later one is reported.
+func coveringStatement(policies []envelope.Policy, teams map[identity.ID][]identity.ID,
+	p envelope.Policy, k int, effect primitive.PolicyEffect,
+	vars map[string]string) (*primitive.PolicyStatement, *envelope.Policy) {
+
+	stmt := p.Body.Policy.Statements[k]
+	resource := expandResource(stmt.Resource, vars)
+
+	for i := range policies {
+		q := &policies[i]
+		if *q.ID != *p.ID {
+			if effect == primitive.PolicyEffectAllow || !attachedToAll(teams[*q.ID], teams[*p.ID]) {
+				continue
+			}
+		}
+
+		for l := range q.Body.Policy.Statements {
+			other := &q.Body.Policy.Statements[l]
+			if other.Effect != effect || (*q.ID == *p.ID && l == k) {
+				continue
+			}
+			if other.Action&stmt.Action != stmt.Action {
+				continue
+			}
+
+			otherResource := expandResource(other.Resource, vars)
+			if !resourceCovers(otherResource, resource) {
+				continue
+			}
+
+			// Equal allow statements cover each other; only the later one
+			// is a no-op.
+			if effect == primitive.PolicyEffectAllow && l > k && other.Action == stmt.Action &&
+				resourceCovers(resource, otherResource) {
+				continue
+			}
+
+			return other, q
+		}
+	}
+
+	return nil, nil
+}
+
+// resourceCovers returns whether the resource covers every path covered by
+// the subject, which may contain globs and alternations itself.
+func resourceCovers(resource, subject string) bool {
+	rparts := strings.Split(resource, "/")
+	sparts := strings.Split(subject, "/")
+	if len(rparts) != len(sparts) || rparts[0] != "" {
+		return false
+	}
+
+	for i := 1; i < len(rparts); i++ {
+		alternatives, err := pathexp.Split("subject", sparts[i])
+		if err != nil {
+			return false
+		}
+
+		for _, a := range alternatives {
+			if !segmentContains(rparts[i], a) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// allEnvironments returns whether the resource covers the secrets of every
+// environment of a project.
+func allEnvironments(resource string) bool {
+	parts := strings.Split(resource, "/")
+	return len(parts) == 8 && parts[3] == "*"
+}
+
+// attachedToAll returns whether the attached teams include every one of the
+// given teams. It's false when there are no teams.
+func attachedToAll(attached, teams []identity.ID) bool {
+	if len(teams) == 0 {
+		return false
+	}
+
+	for _, t := range teams {
+		found := false
+		for _, a := range attached {
+			if a == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sameStatements returns whether both policies have the same statements,
+// in any order, once ${org} is replaced.
+func sameStatements(a, b *primitive.Policy, vars map[string]string) bool {
+	ak := statementKeys(expandStatements(a.Policy.Statements, vars))
+	bk := statementKeys(expandStatements(b.Policy.Statements, vars))
+	if len(ak) != len(bk) {
+		return false
+	}
+	for i := range ak {
+		if ak[i] != bk[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func expandStatements(statements []primitive.PolicyStatement,
+	vars map[string]string) []primitive.PolicyStatement {
+	out := make([]primitive.PolicyStatement, len(statements))
+	for i, s := range statements {
+		out[i] = s
+		out[i].Resource = expandResource(s.Resource, vars)
+	}
+
+	return out
+}
+
+func lintPoliciesCmd(ctx *cli.Context) error {
+	failOn, err := parseLintSeverity(ctx.String("fail-on"))
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, _, _, err := selectOrg(c, client, ctx.String("org"), false)
+	if err != nil {
+		return err
+	}
+
+	policies, err := client.Policies.List(c, org.ID, "")
+	if err != nil {
+		return errs.NewErrorExitError(policyListFailed, err)
+	}
+
+	attachments, err := client.Policies.AttachmentsList(c, org.ID, nil, nil)
+	if err != nil {
+		return errs.NewErrorExitError(policyListFailed, err)
+	}
+
+	findings := lintPolicies(org, policies, attachments)
+
+	failed := 0
+	for _, f := range findings {
+		if f.Severity >= failOn {
+			failed++
+		}
+	}
+
+	if structuredOutput(ctx) {
+		out := make([]lintFindingOutput, len(findings))
+		for i, f := range findings {
+			out[i] = lintFindingOutput{
+				Severity: f.Severity.String(),
+				Check:    f.Check,
+				Policy:   f.Policy,
+				Message:  f.Message,
+			}
+			if f.Statement != nil {
+				out[i].Statement = &statementOutput{
+					Effect:   f.Statement.Effect.String(),
+					Action:   f.Statement.Action.ShortString(),
+					Resource: f.Statement.Resource,
+				}
+			}
+		}
+
+		if err := writeOutput(ctx, os.Stdout, out); err != nil {
+			return err
+		}
+	} else {
+		displayLintFindings(findings)
+	}
+
+	if failed > 0 {
+		return errs.NewExitError(fmt.Sprintf("Found %d problem%s of %s severity or above.",
+			failed, plural(failed), failOn.String()))
+	}
+
+	return nil
+}
+
+func displayLintFindings(findings []lintFinding) {
+	if len(findings) == 0 {
+		fmt.Println("No problems found.")
+		return
+	}
+
+	colors := map[lintSeverity]ui.Color{lintError: ui.Red, lintWarning: ui.Yellow}
+
+	fmt.Println("")
+	w := ansiterm.NewTabWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", ui.BoldString("Severity"), ui.BoldString("Policy"),
+		ui.BoldString("Check"), ui.BoldString("Statement"), ui.BoldString("Message"))
+	for _, f := range findings {
+		severity := ui.FaintString(f.Severity.String())
+		if c, ok := colors[f.Severity]; ok {
+			severity = ui.ColorString(c, f.Severity.String())
+		}
+
+		statement := ""
+		if f.Statement != nil {
+			statement = fmt.Sprintf("%s %s %s", f.Statement.Effect.String(),
+				f.Statement.Action.ShortString(), f.Statement.Resource)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", severity, f.Policy, f.Check, statement, f.Message)
+	}
+	w.Flush()
+	fmt.Println("")
+}
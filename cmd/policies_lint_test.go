@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestLintPolicies(t *testing.T) {
+	allow := primitive.PolicyEffect(primitive.PolicyEffectAllow)
+	deny := primitive.PolicyEffect(primitive.PolicyEffectDeny)
+	read := primitive.PolicyAction(primitive.PolicyActionRead)
+	list := primitive.PolicyAction(primitive.PolicyActionList)
+
+	f := newPolicyFixture(t)
+	f.policy(t, "user", "deny-prod-read", "", []primitive.PolicyStatement{
+		{Effect: deny, Action: read | list, Resource: "/${org}/p/[prod|staging]/*/*/*/*"},
+	}, "ops", "admin")
+	f.policy(t, "user", "copy-of-dev", "", []primitive.PolicyStatement{
+		{Effect: allow, Action: read, Resource: "/${org}/p/dev/*/*/*/*"},
+	}, "ci")
+	f.policy(t, "user", "ci-write", "", []primitive.PolicyStatement{
+		{Effect: allow, Action: allPolicyActions, Resource: "/o/p/*/*/*/*/*"},
+		{Effect: allow, Action: read, Resource: "/o/p/dev/*/*/*/*"},
+	}, "ci")
+	f.policy(t, "user", "unused", "", []primitive.PolicyStatement{
+		{Effect: allow, Action: read, Resource: "/o/q/dev/*/*/*/*"},
+		{Effect: allow, Action: read, Resource: "/${org}/q/dev/*/*/*/*"},
+	})
+
+	findings := lintPolicies(f.org, f.policies, f.attachments)
+
+	got := []string{}
+	for _, finding := range findings {
+		line := finding.Severity.String() + " " + finding.Check + " " + finding.Policy
+		if finding.Statement != nil {
+			line += " " + finding.Statement.Resource
+		}
+		got = append(got, line)
+	}
+
+	want := []string{
+		"error write-all-envs ci-write /o/p/*/*/*/*/*",
+		"warning no-op ci-write /o/p/dev/*/*/*/*",
+		"warning duplicate generated-allow-1",
+		"warning shadowed read-prod /o/p/prod/*/*/*/*",
+		"warning no-op unused /${org}/q/dev/*/*/*/*",
+		"info unattached unused",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected findings:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestLintPoliciesPartiallySharedDeny(t *testing.T) {
+	f := newPolicyFixture(t)
+
+	// read-prod is attached to ops and admin, so admin can still read
+	// production secrets.
+	f.policy(t, "user", "deny-prod-read", "", []primitive.PolicyStatement{
+		{Effect: primitive.PolicyEffectDeny, Action: primitive.PolicyActionRead | primitive.PolicyActionList,
+			Resource: "/o/p/prod/*/*/*/*"},
+	}, "ops")
+
+	for _, finding := range lintPolicies(f.org, f.policies, f.attachments) {
+		if finding.Check == lintShadowed {
+			t.Errorf("expected no shadowed statements, got %s in %s", finding.Message, finding.Policy)
+		}
+	}
+}
+
+func TestResourceCovers(t *testing.T) {
+	tcs := []struct {
+		resource string
+		subject  string
+		want     bool
+	}{
+		{"/o/p/*/*/*/*/*", "/o/p/[dev|prod]/*/*/*/*", true},
+		{"/o/p/[dev|prod|stage]/*/*/*/*", "/o/p/[dev|prod]/*/*/*/*", true},
+		{"/o/p/[dev|stage]/*/*/*/*", "/o/p/[dev|prod]/*/*/*/*", false},
+		{"/o/p/prod*/*/*/*/*", "/o/p/production/*/*/*/*", true},
+		{"/o/p/prod/*/*/*/*", "/o/p/prod*/*/*/*/*", false},
+		{"/o/p/dev/*/*/*/*", "/o/p/*/*/*/*/*", false},
+		{"/o/*", "/o/p/*/*/*/*/*", false},
+	}
+
+	for _, tc := range tcs {
+		if got := resourceCovers(tc.resource, tc.subject); got != tc.want {
+			t.Errorf("resourceCovers(%q, %q) expected %t, got %t", tc.resource, tc.subject, tc.want, got)
+		}
+	}
+}
+
+func TestParseLintSeverity(t *testing.T) {
+	if s, err := parseLintSeverity("warning"); err != nil || s != lintWarning {
+		t.Errorf("parseLintSeverity(warning) expected warning, got %s, %v", s, err)
+	}
+	if _, err := parseLintSeverity("fatal"); err == nil {
+		t.Error("parseLintSeverity(fatal) expected an error")
+	}
+}
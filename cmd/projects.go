@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -40,10 +41,11 @@ func init() {
 				Usage: "List services for an organization",
 				Flags: []cli.Flag{
 					orgFlag("List projects in an organization", false),
+					outputFlag,
 				},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					setUserEnv, checkRequiredFlags, listProjectsCmd,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, setUserEnv, checkRequiredFlags, listProjectsCmd,
 				),
 			},
 		},
@@ -53,6 +55,13 @@ func init() {
 
 const projectListFailed = "Could not list projects, please try again."
 
+// projectOutput is the structured output of a project
+type projectOutput struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Org  string `json:"org"`
+}
+
 func listProjectsCmd(ctx *cli.Context) error {
 
 	cfg, err := config.LoadConfig()
@@ -73,6 +82,14 @@ func listProjectsCmd(ctx *cli.Context) error {
 		return errs.NewErrorExitError("Failed to retrieve projects list.", err)
 	}
 
+	if structuredOutput(ctx) {
+		out := make([]projectOutput, len(projects))
+		for i, project := range projects {
+			out[i] = projectOutput{ID: project.ID.String(), Name: project.Body.Name, Org: org.Body.Name}
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	fmt.Println("")
 	fmt.Printf("%s\n", ui.BoldString("Projects"))
 	for _, project := range projects {
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/hints"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/prompts"
+)
+
+func init() {
+	promote := cli.Command{
+		Name:      "promote",
+		Usage:     "Copy secrets from one environment or service to another",
+		ArgsUsage: "<source path> <destination path>",
+		Category:  "SECRETS",
+		Flags: []cli.Flag{
+			orgFlag("Use this organization for paths that don't include one.", false),
+			projectFlag("Use this project for paths that don't include one.", false),
+			serviceFlag("Use this service for paths that don't include one.", "default", false),
+			newSlicePlaceholder("name, n", "NAME", "Only promote secrets matching this name (e.g. db_*)",
+				"", "", false),
+			cli.BoolFlag{
+				Name:  "only-missing",
+				Usage: "Only promote secrets which do not exist at the destination",
+			},
+			cli.BoolFlag{
+				Name:  "overwrite",
+				Usage: "Replace secrets which have a different value at the destination",
+			},
+			stdAutoAcceptFlag,
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			checkRequiredFlags, promoteCmd,
+		),
+	}
+
+	Cmds = append(Cmds, promote)
+}
+
+func promoteCmd(ctx *cli.Context) error {
+	if err := argCheck(ctx, 2, 2); err != nil {
+		return err
+	}
+
+	args := ctx.Args()
+	src, err := parseServicePath(ctx, args[0])
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	dst, err := parseServicePath(ctx, args[1])
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	if src.String() == dst.String() {
+		return errs.NewUsageExitError("The source and destination paths must differ", ctx)
+	}
+
+	onlyMissing := ctx.Bool("only-missing")
+	overwrite := ctx.Bool("overwrite")
+	if onlyMissing && overwrite {
+		return errs.NewUsageExitError("Only one of --only-missing and --overwrite can be supplied", ctx)
+	}
+
+	patterns := ctx.StringSlice("name")
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return errs.NewUsageExitError("Invalid name pattern: "+p, ctx)
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	secrets, err := fetchServicePaths(c, client, src, dst)
+	if err != nil {
+		return err
+	}
+
+	selected := filterSecretsByName(secrets[0], patterns)
+	if len(selected) == 0 {
+		return errs.NewExitError("No secrets found to promote.")
+	}
+
+	diffs, err := planPromotion(selected, secrets[1], onlyMissing, overwrite)
+	if err != nil {
+		return errs.NewExitError(err.Error())
+	}
+
+	if len(diffs) == 0 {
+		fmt.Printf("Nothing to promote, %s is up to date.\n", dst)
+		return nil
+	}
+
+	fmt.Printf("The following secrets will be set at %s:\n\n", dst)
+	writeDiff(os.Stdout, diffs, false)
+	fmt.Println()
+
+	if !ctx.Bool("yes") {
+		preamble := fmt.Sprintf("You are about to set %d secret%s at %s.", len(diffs), plural(len(diffs)), dst)
+		success, err := prompts.Confirm(nil, &preamble, true, false)
+		if err != nil {
+			return errs.NewErrorExitError("Failed to retrieve confirmation", err)
+		}
+		if !success {
+			return errs.ErrAbort
+		}
+	}
+
+	pe, err := pathexp.New(dst.Org, dst.Project, []string{dst.Env}, []string{dst.Service},
+		[]string{"*"}, []string{"*"})
+	if err != nil {
+		return errs.NewErrorExitError("Error deriving credential path", err)
+	}
+
+	// The source values are used as is, so numbers stay numbers.
+	makers := valueMakers{}
+	for _, d := range diffs {
+		makers[d.Name] = func(value *apitypes.CredentialValue) valueMaker {
+			return func() *apitypes.CredentialValue { return value }
+		}(d.B)
+	}
+
+	s, p := spinner("Attempting to set credentials")
+	s.Start()
+	creds, err := setCredentials(ctx, pe, makers, p)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Could not set credentials.", err)
+	}
+
+	fmt.Println()
+	for _, cred := range creds {
+		name := (*cred.Body).GetName()
+		pe := (*cred.Body).GetPathExp()
+		fmt.Printf("Credential %s has been set at %s/%s\n", name, displayPathExp(pe), name)
+	}
+
+	hints.Display(hints.View, hints.Run)
+	return nil
+}
+
+// filterSecretsByName returns the secrets whose names match any of the given
+// patterns. If no patterns are given, all secrets are returned.
+func filterSecretsByName(secrets []apitypes.CredentialEnvelope, patterns []string) []apitypes.CredentialEnvelope {
+	if len(patterns) == 0 {
+		return secrets
+	}
+
+	filtered := []apitypes.CredentialEnvelope{}
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		for _, p := range patterns {
+			if ok, _ := path.Match(strings.ToLower(p), name); ok {
+				filtered = append(filtered, secret)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+// planPromotion returns the secrets from src that need to be set at dst. Each
+// difference's A value is the one at dst, and its B value the one from src.
+//
+// Secrets missing from dst are always included. Secrets with a different
+// value at dst are skipped if onlyMissing is set, and included if overwrite is
+// set. If neither is set, any such secret is an error, so values are never
+// replaced by accident.
+func planPromotion(src, dst []apitypes.CredentialEnvelope, onlyMissing, overwrite bool) ([]secretDiff, error) {
+	plan := []secretDiff{}
+	conflicts := []string{}
+
+	for _, d := range diffSecrets(dst, src) {
+		switch d.Kind {
+		case diffAdded:
+			plan = append(plan, d)
+		case diffChanged:
+			switch {
+			case overwrite:
+				plan = append(plan, d)
+			case !onlyMissing:
+				conflicts = append(conflicts, d.Name)
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("The following secrets have a different value at the destination: %s\n"+
+			"Use --overwrite to replace them, or --only-missing to skip them.", strings.Join(conflicts, ", "))
+	}
+
+	return plan, nil
+}
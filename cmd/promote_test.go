@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+func promoteCredentialsHelper(values map[string]*apitypes.CredentialValue) []apitypes.CredentialEnvelope {
+	var creds []apitypes.CredentialEnvelope
+	for name, value := range values {
+		var body apitypes.Credential = &apitypes.CredentialV2{
+			State:          "set",
+			BaseCredential: apitypes.BaseCredential{Name: name, Value: value},
+		}
+		creds = append(creds, apitypes.CredentialEnvelope{Body: &body})
+	}
+
+	return creds
+}
+
+func TestFilterSecretsByName(t *testing.T) {
+	creds := promoteCredentialsHelper(map[string]*apitypes.CredentialValue{
+		"db_user":     apitypes.NewStringCredentialValue("user"),
+		"db_password": apitypes.NewStringCredentialValue("hunter2"),
+		"port":        apitypes.NewIntCredentialValue(80),
+	})
+
+	if got := filterSecretsByName(creds, nil); len(got) != 3 {
+		t.Errorf("filterSecretsByName() expected all secrets without patterns, got %d", len(got))
+	}
+
+	got := filterSecretsByName(creds, []string{"DB_*"})
+	if len(got) != 2 {
+		t.Fatalf("filterSecretsByName() expected 2 secrets, got %d", len(got))
+	}
+	for _, c := range got {
+		if name := (*c.Body).GetName(); name == "port" {
+			t.Errorf("filterSecretsByName() expected port to be filtered out")
+		}
+	}
+}
+
+func TestPlanPromotion(t *testing.T) {
+	src := promoteCredentialsHelper(map[string]*apitypes.CredentialValue{
+		"missing": apitypes.NewIntCredentialValue(80),
+		"changed": apitypes.NewStringCredentialValue("new"),
+		"same":    apitypes.NewStringCredentialValue("same"),
+	})
+	dst := promoteCredentialsHelper(map[string]*apitypes.CredentialValue{
+		"changed": apitypes.NewStringCredentialValue("old"),
+		"same":    apitypes.NewStringCredentialValue("same"),
+		"extra":   apitypes.NewStringCredentialValue("extra"),
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		_, err := planPromotion(src, dst, false, false)
+		if err == nil {
+			t.Error("planPromotion() expected an error for a changed secret")
+		}
+	})
+
+	t.Run("only missing", func(t *testing.T) {
+		plan, err := planPromotion(src, dst, true, false)
+		if err != nil {
+			t.Fatalf("planPromotion() expected no errors, got %s", err)
+		}
+
+		if len(plan) != 1 || plan[0].Name != "missing" {
+			t.Fatalf("planPromotion() expected only missing, got %v", plan)
+		}
+
+		if raw, _ := plan[0].B.Raw(); raw != 80 {
+			t.Errorf("planPromotion() expected the source value to keep its type, got %#v", raw)
+		}
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		plan, err := planPromotion(src, dst, false, true)
+		if err != nil {
+			t.Fatalf("planPromotion() expected no errors, got %s", err)
+		}
+
+		if len(plan) != 2 || plan[0].Name != "changed" || plan[1].Name != "missing" {
+			t.Fatalf("planPromotion() expected changed and missing, got %v", plan)
+		}
+
+		if plan[0].B.String() != "new" {
+			t.Errorf("planPromotion() expected the source value, got %s", plan[0].B.String())
+		}
+	})
+}
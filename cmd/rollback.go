@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/prompts"
+)
+
+func init() {
+	rollback := cli.Command{
+		Name:      "rollback",
+		Usage:     "Restore a secret to the value of a previous version",
+		ArgsUsage: "<name|path>",
+		Category:  "SECRETS",
+		Flags: append(setUnsetFlags,
+			newPlaceholder("steps, n", "N", "Restore the version N versions before the current one (default: 1)",
+				"", "", false),
+			newPlaceholder("id", "ID", "Restore the version with this credential ID", "", "", false),
+			stdAutoAcceptFlag,
+		),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setSliceDefaults, rollbackCmd,
+		),
+	}
+
+	Cmds = append(Cmds, rollback)
+}
+
+func rollbackCmd(ctx *cli.Context) error {
+	if err := argCheck(ctx, 1, 1); err != nil {
+		return err
+	}
+
+	if ctx.String("steps") != "" && ctx.String("id") != "" {
+		return errs.NewUsageExitError("Only one of --steps and --id can be supplied", ctx)
+	}
+
+	steps := 1
+	if ctx.String("steps") != "" {
+		var err error
+		steps, err = strconv.Atoi(ctx.String("steps"))
+		if err != nil || steps < 1 {
+			return errs.NewUsageExitError("--steps must be a positive number", ctx)
+		}
+	}
+
+	var id *identity.ID
+	if ctx.String("id") != "" {
+		decoded, err := identity.DecodeFromString(ctx.String("id"))
+		if err != nil {
+			return errs.NewUsageExitError("Invalid credential ID: "+ctx.String("id"), ctx)
+		}
+		id = &decoded
+	}
+
+	pe, name, err := determinePath(ctx, ctx.Args()[0])
+	if err != nil {
+		return errs.NewErrorExitError("Could not roll back credential", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	s, p := spinner("Retrieving history")
+	s.Start()
+	history, err := client.Credentials.History(c, pe.String(), *name, false, p)
+	s.Stop()
+	if err != nil {
+		if apitypes.IsNotFoundError(err) {
+			return errs.NewExitError(fmt.Sprintf("Secret %s/%s not found.", displayPathExp(pe), *name))
+		}
+		return errs.NewErrorExitError("Could not retrieve history", err)
+	}
+
+	target, err := selectRollbackTarget(history, steps, id)
+	if err != nil {
+		return errs.NewExitError(err.Error())
+	}
+
+	head := history[0]
+	value := (*target.Credential.Body).GetValue()
+	if current := (*head.Credential.Body).GetValue(); current != nil && credentialValuesEqual(current, value) {
+		fmt.Printf("Credential %s already has the value of version %d.\n", *name, target.CredentialVersion)
+		return nil
+	}
+
+	if !ctx.Bool("yes") {
+		preamble := fmt.Sprintf("You are about to roll back \"%s/%s\" from version %d to the value of version %d.",
+			displayPathExp(pe), *name, head.CredentialVersion, target.CredentialVersion)
+		success, err := prompts.Confirm(nil, &preamble, true, false)
+		if err != nil {
+			return errs.NewErrorExitError("Failed to retrieve confirmation", err)
+		}
+		if !success {
+			return errs.ErrAbort
+		}
+	}
+
+	makers := valueMakers{}
+	makers[*name] = func() *apitypes.CredentialValue {
+		return value
+	}
+
+	s, p = spinner(fmt.Sprintf("Attempting to roll back credential %s", *name))
+	s.Start()
+	_, err = setCredentials(ctx, pe, makers, p)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Could not roll back credential", err)
+	}
+
+	fmt.Printf("\nCredential %s has been rolled back at %s/%s to the value of version %d (%s).\n",
+		*name, displayPathExp(pe), *name, target.CredentialVersion, target.Credential.ID)
+
+	return nil
+}
+
+// selectRollbackTarget returns the version in history to roll back to, either
+// the version with the given ID, or the version the given number of steps
+// before the current one. Unset versions can't be restored.
+func selectRollbackTarget(history []apitypes.CredentialHistoryItem, steps int, id *identity.ID) (*apitypes.CredentialHistoryItem, error) {
+	var target *apitypes.CredentialHistoryItem
+	if id != nil {
+		for i, h := range history {
+			if h.Credential.ID != nil && *h.Credential.ID == *id {
+				if i == 0 {
+					return nil, fmt.Errorf("Credential %s is the current version", id)
+				}
+				target = &history[i]
+				break
+			}
+		}
+
+		if target == nil {
+			return nil, fmt.Errorf("Credential %s is not a version of this secret", id)
+		}
+	} else {
+		if steps >= len(history) {
+			return nil, fmt.Errorf("Cannot go back %d version%s, the secret has %d previous version%s",
+				steps, plural(steps), len(history)-1, plural(len(history)-1))
+		}
+		target = &history[steps]
+	}
+
+	if (*target.Credential.Body).GetValue() == nil {
+		return nil, fmt.Errorf("Version %d unset the secret, and cannot be restored", target.CredentialVersion)
+	}
+
+	return target, nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+func TestSelectRollbackTarget(t *testing.T) {
+	item := func(raw string, version int, value *apitypes.CredentialValue) apitypes.CredentialHistoryItem {
+		id, err := identity.DecodeFromString(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		state := "set"
+		if value.IsUnset() {
+			state = "unset"
+		}
+
+		var body apitypes.Credential = &apitypes.CredentialV2{
+			State:          state,
+			BaseCredential: apitypes.BaseCredential{Name: "foo", Value: value},
+		}
+		return apitypes.CredentialHistoryItem{
+			Credential:        apitypes.CredentialEnvelope{ID: &id, Body: &body},
+			CredentialVersion: version,
+		}
+	}
+
+	history := []apitypes.CredentialHistoryItem{
+		item("04100000000000000000000000100", 4, apitypes.NewStringCredentialValue("bad")),
+		item("04100000000000000000000000010", 3, apitypes.NewUnsetCredentialValue()),
+		item("04100000000000000000000000001", 2, apitypes.NewIntCredentialValue(80)),
+	}
+
+	tcs := []struct {
+		name    string
+		steps   int
+		id      *identity.ID
+		version int
+	}{
+		{"one step to unset", 1, nil, 0},
+		{"two steps", 2, nil, 2},
+		{"too many steps", 3, nil, 0},
+		{"by id", 1, history[2].Credential.ID, 2},
+		{"current id", 1, history[0].Credential.ID, 0},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			target, err := selectRollbackTarget(history, tc.steps, tc.id)
+			if tc.version == 0 {
+				if err == nil {
+					t.Errorf("selectRollbackTarget() expected an error, got version %d", target.CredentialVersion)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("selectRollbackTarget() expected no errors, got %s", err)
+			}
+			if target.CredentialVersion != tc.version {
+				t.Errorf("selectRollbackTarget() expected version %d, got %d", tc.version, target.CredentialVersion)
+			}
+		})
+	}
+
+	t.Run("unknown id", func(t *testing.T) {
+		id, err := identity.DecodeFromString("04100000000000000000000001000")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := selectRollbackTarget(history, 1, &id); err == nil {
+			t.Error("selectRollbackTarget() expected an error for an unknown id")
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/prefs"
+)
+
+// rotationInput is written as JSON to the stdin of exec rotation plugins.
+//
+// When rotating, the plugin writes the new value to stdout. If the new value
+// can't be stored, the plugin is run again to roll back, with NewValue set to
+// the value it returned.
+type rotationInput struct {
+	Action   string      `json:"action"`
+	Path     string      `json:"path"`
+	Name     string      `json:"name"`
+	Value    interface{} `json:"value"`
+	NewValue interface{} `json:"new_value,omitempty"`
+}
+
+// findRotation returns the rotation with the most specific path expression
+// containing pe, for the secret with the given name. Rotations for the secret
+// by name are preferred over those for all secrets (*). It returns nil if the
+// secret has no rotation.
+func findRotation(rotations []prefs.SecretRotation, pe *pathexp.PathExp, name string) *prefs.SecretRotation {
+	var match *prefs.SecretRotation
+	for i, r := range rotations {
+		if (r.Name != name && r.Name != "*") || !r.PathExp.Contains(pe) {
+			continue
+		}
+
+		switch {
+		case match == nil:
+		case match.Name == "*" && r.Name != "*":
+		case match.Name == r.Name && r.PathExp.CompareSpecificity(match.PathExp) > 0:
+		default:
+			continue
+		}
+		match = &rotations[i]
+	}
+
+	return match
+}
+
+// rotateSecret sets a new value for the secret with the given name at the
+// given path, using the given rotation. If the new value can't be stored, an
+// exec plugin is asked to roll back its change.
+func rotateSecret(ctx *cli.Context, client *api.Client, r *prefs.SecretRotation,
+	pe *pathexp.PathExp, name string) error {
+
+	// Only exec plugins are given the old value, so it's only fetched for them.
+	var old *apitypes.CredentialValue
+	if r.Plugin == "exec" {
+		var err error
+		old, err = client.Credentials.Current(context.Background(), pe.String(), name)
+		if apitypes.IsNotFoundError(err) {
+			return fmt.Errorf("Secret %s does not exist at %s", name, displayPathExp(pe))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	values, err := rotatedValues(r, pe, name, old)
+	if err != nil {
+		return err
+	}
+
+	makers := valueMakers{}
+	for n, v := range values {
+		makers[n] = func(v *apitypes.CredentialValue) valueMaker {
+			return func() *apitypes.CredentialValue { return v }
+		}(v)
+	}
+
+	_, err = setCredentials(ctx, pe, makers, nil)
+	if err == nil || r.Plugin != "exec" {
+		return err
+	}
+
+	input := newRotationInput("rollback", pe, name, old)
+	input.NewValue, _ = values[name].Raw()
+	if _, rerr := runRotationPlugin(r.Args, input); rerr != nil {
+		return fmt.Errorf("%s; rolling back also failed: %s", err, rerr)
+	}
+
+	return fmt.Errorf("%s; the change has been rolled back", err)
+}
+
+// rotatedValues returns the new values for the secret with the given name,
+// and its old value, keyed by name. Generated keypairs have two values.
+func rotatedValues(r *prefs.SecretRotation, pe *pathexp.PathExp, name string,
+	old *apitypes.CredentialValue) (map[string]*apitypes.CredentialValue, error) {
+
+	switch r.Plugin {
+	case "generate":
+		generated, err := generateValues(name, r.Args[0], r.Length, r.Alphabet, r.Bits)
+		if err != nil {
+			return nil, err
+		}
+
+		values := make(map[string]*apitypes.CredentialValue, len(generated))
+		for n, v := range generated {
+			values[n] = apitypes.NewStringCredentialValue(v)
+		}
+		return values, nil
+	case "exec":
+		out, err := runRotationPlugin(r.Args, newRotationInput("rotate", pe, name, old))
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := rotatedValue(old, out)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]*apitypes.CredentialValue{name: value}, nil
+	default:
+		return nil, fmt.Errorf("Unknown rotation plugin %q", r.Plugin)
+	}
+}
+
+func newRotationInput(action string, pe *pathexp.PathExp, name string, old *apitypes.CredentialValue) *rotationInput {
+	input := &rotationInput{Action: action, Path: pe.String(), Name: name}
+	if old != nil {
+		input.Value, _ = old.Raw()
+	}
+
+	return input
+}
+
+// rotationPluginTimeout is how long an exec rotation plugin may run before
+// it's killed.
+const rotationPluginTimeout = 2 * time.Minute
+
+// runRotationPlugin runs the plugin command with the given input, returning
+// its output, without a trailing newline. The plugin is killed if it runs for
+// longer than rotationPluginTimeout.
+func runRotationPlugin(args []string, input *rotationInput) (string, error) {
+	in, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+
+	c, cancel := context.WithTimeout(context.Background(), rotationPluginTimeout)
+	defer cancel()
+
+	out := &bytes.Buffer{}
+	cmd := exec.CommandContext(c, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		if c.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("Rotation plugin %s did not finish within %s", args[0], rotationPluginTimeout)
+		}
+		return "", fmt.Errorf("Rotation plugin %s failed: %s", args[0], err)
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(out.String(), "\n"), "\r"), nil
+}
+
+// rotatedValue returns the output of a rotation plugin as a value of the same
+// type as the old value.
+func rotatedValue(old *apitypes.CredentialValue, out string) (*apitypes.CredentialValue, error) {
+	if out == "" {
+		return nil, errors.New("Rotation plugin did not output a new value")
+	}
+
+	typ := "string"
+	if old != nil {
+		switch raw, _ := old.Raw(); raw.(type) {
+		case int, int64:
+			typ = "int"
+		case float64:
+			typ = "float"
+		}
+	}
+
+	return parseTypedValue(typ, []byte(out))
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/prefs"
+)
+
+func TestFindRotation(t *testing.T) {
+	mustPathExp := func(raw string) *pathexp.PathExp {
+		pe, err := pathexp.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pe
+	}
+
+	rotations := []prefs.SecretRotation{
+		{PathExp: mustPathExp("/o/p/*/*/*/*"), Name: "*", Plugin: "generate", Args: []string{"hex"}},
+		{PathExp: mustPathExp("/o/p/*/*/*/*"), Name: "db", Plugin: "exec", Args: []string{"all-envs"}},
+		{PathExp: mustPathExp("/o/p/production/*/*/*"), Name: "db", Plugin: "exec", Args: []string{"production"}},
+		{PathExp: mustPathExp("/o/p/production/*/*/*"), Name: "*", Plugin: "generate", Args: []string{"uuid"}},
+	}
+
+	tcs := []struct {
+		pe   string
+		name string
+		want string
+	}{
+		{"/o/p/production/s/*/*", "db", "exec production"},
+		{"/o/p/staging/s/*/*", "db", "exec all-envs"},
+		{"/o/p/production/s/*/*", "token", "generate uuid"},
+		{"/o/p/staging/s/*/*", "token", "generate hex"},
+		{"/o/q/production/s/*/*", "db", ""},
+	}
+
+	for _, tc := range tcs {
+		r := findRotation(rotations, mustPathExp(tc.pe), tc.name)
+		got := ""
+		if r != nil {
+			got = r.String()
+		}
+
+		if got != tc.want {
+			t.Errorf("findRotation(%s, %s) expected %q, got %q", tc.pe, tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestRotatedValue(t *testing.T) {
+	value, err := rotatedValue(apitypes.NewIntCredentialValue(1), "2")
+	if err != nil || value.String() != "2" {
+		t.Fatalf("rotatedValue() expected 2, got %v (%v)", value, err)
+	}
+	if raw, _ := value.Raw(); raw != 2 {
+		t.Errorf("rotatedValue() expected an int, got %#v", raw)
+	}
+
+	if _, err := rotatedValue(apitypes.NewIntCredentialValue(1), "two"); err == nil {
+		t.Error("rotatedValue() expected an error for a mistyped value")
+	}
+
+	if _, err := rotatedValue(apitypes.NewStringCredentialValue("a"), ""); err == nil {
+		t.Error("rotatedValue() expected an error for an empty value")
+	}
+}
+
+func TestRotatedValues(t *testing.T) {
+	pe, err := pathexp.Parse("/o/p/e/s/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := apitypes.NewStringCredentialValue("old")
+
+	r := &prefs.SecretRotation{Plugin: "generate", Args: []string{"hex", "8"}, Length: 8}
+	values, err := rotatedValues(r, pe, "token", old)
+	if err != nil {
+		t.Fatalf("rotatedValues() expected no errors, got %s", err)
+	}
+	if v, ok := values["token"]; !ok || len(v.String()) != 16 {
+		t.Errorf("rotatedValues() expected a 16 character hex value, got %v", values)
+	}
+
+	r = &prefs.SecretRotation{Plugin: "generate", Args: []string{"password", "12", "alphabet=numeric"},
+		Length: 12, Alphabet: "numeric"}
+	values, err = rotatedValues(r, pe, "pin", old)
+	if err != nil {
+		t.Fatalf("rotatedValues() expected no errors, got %s", err)
+	}
+	if v, ok := values["pin"]; !ok || len(v.String()) != 12 || strings.Trim(v.String(), "0123456789") != "" {
+		t.Errorf("rotatedValues() expected a 12 digit password, got %v", values)
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("exec plugins are tested with sh")
+	}
+
+	r = &prefs.SecretRotation{Plugin: "exec", Args: []string{"sh", "-c", `grep -q '"action":"rotate"' && echo new`}}
+	values, err = rotatedValues(r, pe, "token", old)
+	if err != nil {
+		t.Fatalf("rotatedValues() expected no errors, got %s", err)
+	}
+	if v := values["token"]; v == nil || v.String() != "new" {
+		t.Errorf("rotatedValues() expected the plugin's output, got %v", values)
+	}
+
+	r = &prefs.SecretRotation{Plugin: "exec", Args: []string{"sh", "-c", "exit 1"}}
+	if _, err := rotatedValues(r, pe, "token", old); err == nil {
+		t.Error("rotatedValues() expected an error when the plugin fails")
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/ui"
 
 	"github.com/urfave/cli"
 )
@@ -24,6 +26,19 @@ func init() {
 			stdProjectFlag,
 			stdEnvFlag,
 			serviceFlag("Use this service.", "default", true),
+			cli.BoolFlag{
+				Name:  "watch, w",
+				Usage: "Restart the command, or send it a signal, when its secrets change",
+			},
+			newPlaceholder("interval", "DURATION", "How often to check for changed secrets when watching",
+				"30s", "TORUS_WATCH_INTERVAL", false),
+			newPlaceholder("debounce", "DURATION", "How long secrets must be unchanged before acting on a change",
+				"5s", "TORUS_WATCH_DEBOUNCE", false),
+			newPlaceholder("signal", "SIGNAL", "Send this signal (e.g. SIGHUP) instead of restarting the command; "+
+				"requires --files, as the command's environment can't be updated",
+				"", "TORUS_WATCH_SIGNAL", false),
+			newSlicePlaceholder("files", "NAME", "Write this secret to a file in the TORUS_SECRETS_DIR directory ('*' for all secrets)",
+				"", "", false),
 		},
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
@@ -48,22 +63,49 @@ func runCmd(ctx *cli.Context) error {
 		return err
 	}
 
-	// Create the command. It gets this processes's stdio.
-	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Env = manipulateEnv(path)
+	aliases, err := loadSecretAliases()
+	if err != nil {
+		return err
+	}
+	secrets = aliasSecrets(secrets, aliases)
 
-	// Add the secrets into the env
-	for _, secret := range secrets {
-		value := (*secret.Body).GetValue()
-		key := strings.ToUpper((*secret.Body).GetName())
+	var files *secretFiles
+	if names := ctx.StringSlice("files"); len(names) > 0 {
+		files, err = newSecretFiles(names)
+		if err != nil {
+			return errs.NewErrorExitError("Could not create secrets directory", err)
+		}
 
-		cmd.Env = append(cmd.Env, key+"="+value.String())
+		err = files.Write(secrets)
+		if err != nil {
+			err = errs.NewErrorExitError("Could not write secret files", err)
+		}
 	}
 
-	err = cmd.Start()
+	if err == nil {
+		if ctx.Bool("watch") {
+			err = watchCmd(ctx, args, secrets, aliases, path, files)
+		} else {
+			err = runOnce(args, path, secrets, files)
+		}
+	}
+
+	// The secrets directory must be removed before exiting with the command's
+	// status, as os.Exit does not run deferred functions.
+	if files != nil {
+		if rerr := files.Remove(); rerr != nil {
+			ui.Warn("Could not remove secrets directory %s: %s", files.Dir, rerr)
+		}
+	}
+
+	return exitWithStatus(err)
+}
+
+// runOnce runs the command, relaying signals to it, and returns the result of
+// waiting for it to exit.
+func runOnce(args []string, path *pathexp.PathExp, secrets []apitypes.CredentialEnvelope, files *secretFiles) error {
+	cmd := runCommand(args, path, secrets, files)
+	err := cmd.Start()
 	if err != nil {
 		return errs.NewErrorExitError("Failed to run command", err)
 	}
@@ -84,6 +126,40 @@ func runCmd(ctx *cli.Context) error {
 
 	err = cmd.Wait()
 	close(done)
+
+	return err
+}
+
+// runCommand creates the command to run, with the given secrets injected into
+// its environment. It gets this processes's stdio.
+//
+// If files is not nil, the path of the secrets directory is set in
+// `TORUS_SECRETS_DIR`.
+func runCommand(args []string, path *pathexp.PathExp, secrets []apitypes.CredentialEnvelope, files *secretFiles) *exec.Cmd {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = manipulateEnv(path)
+
+	// Add the secrets into the env
+	for _, secret := range secrets {
+		value := (*secret.Body).GetValue()
+		key := strings.ToUpper((*secret.Body).GetName())
+
+		cmd.Env = append(cmd.Env, key+"="+value.String())
+	}
+
+	if files != nil {
+		cmd.Env = append(cmd.Env, "TORUS_SECRETS_DIR="+files.Dir)
+	}
+
+	return cmd
+}
+
+// exitWithStatus exits with the status of the finished command, if it failed
+// with one.
+func exitWithStatus(err error) error {
 	if err != nil {
 		if exiterr, ok := err.(*exec.ExitError); ok {
 			if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+// secretFiles is a private directory that secrets are written into as files,
+// for `torus run --files`. Each file is named after its secret.
+type secretFiles struct {
+	Dir   string
+	names []string
+}
+
+// newSecretFiles creates a new directory for the named secrets. A name of "*"
+// selects all secrets. Names are case insensitive, as secret names are stored
+// in lowercase.
+//
+// Where possible the directory is created on a memory backed filesystem, so
+// the secrets are never written to disk.
+func newSecretFiles(names []string) (*secretFiles, error) {
+	// TempDir creates the directory with 0700 permissions.
+	dir, err := ioutil.TempDir(secretsDirBase(), "torus-")
+	if err != nil {
+		return nil, err
+	}
+
+	lower := make([]string, len(names))
+	for i, name := range names {
+		lower[i] = strings.ToLower(name)
+	}
+
+	return &secretFiles{Dir: dir, names: lower}, nil
+}
+
+// secretsDirBase returns the directory that secrets directories are created
+// in, preferring the per-user runtime directory, followed by /dev/shm. Both
+// are normally tmpfs mounts. If neither exist, the system's temporary
+// directory is used.
+func secretsDirBase() string {
+	for _, dir := range []string{os.Getenv("XDG_RUNTIME_DIR"), "/dev/shm"} {
+		if dir == "" {
+			continue
+		}
+
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			return dir
+		}
+	}
+
+	return ""
+}
+
+// Write writes the selected secrets into the directory, replacing any existing
+// files. Files for secrets that no longer exist are removed.
+func (f *secretFiles) Write(secrets []apitypes.CredentialEnvelope) error {
+	values := make(map[string]string)
+	for _, secret := range secrets {
+		values[(*secret.Body).GetName()] = (*secret.Body).GetValue().String()
+	}
+
+	selected := make(map[string]string)
+	for _, name := range f.names {
+		if name == "*" {
+			for k, v := range values {
+				selected[k] = v
+			}
+			continue
+		}
+
+		v, ok := values[name]
+		if !ok {
+			return fmt.Errorf("secret %q not found", name)
+		}
+		selected[name] = v
+	}
+
+	for name := range selected {
+		if err := checkFileName(name); err != nil {
+			return err
+		}
+	}
+
+	for name, value := range selected {
+		// Write to a temporary file first, so a process reloading its
+		// configuration never sees a partially written secret.
+		tmp, err := ioutil.TempFile(f.Dir, "."+name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tmp.WriteString(value)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Chmod(tmp.Name(), 0600)
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), filepath.Join(f.Dir, name))
+		}
+		if err != nil {
+			wipeFile(tmp.Name())
+			return err
+		}
+	}
+
+	existing, err := ioutil.ReadDir(f.Dir)
+	if err != nil {
+		return err
+	}
+
+	for _, fi := range existing {
+		if _, ok := selected[fi.Name()]; !ok {
+			err := wipeFile(filepath.Join(f.Dir, fi.Name()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkFileName returns an error if the name can't be used as the name of a
+// file inside the directory. Secret names are already restricted, but names
+// may also come from aliases, so anything which could escape the directory,
+// or be hidden within it, is rejected here too.
+func checkFileName(name string) error {
+	if name == "" || strings.HasPrefix(name, ".") || strings.Contains(name, "..") ||
+		strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("secret %q cannot be written to a file, as its name is not a valid file name", name)
+	}
+
+	return nil
+}
+
+// Remove overwrites and removes every file in the directory, then removes the
+// directory itself.
+func (f *secretFiles) Remove() error {
+	existing, err := ioutil.ReadDir(f.Dir)
+	if err != nil {
+		return err
+	}
+
+	for _, fi := range existing {
+		err := wipeFile(filepath.Join(f.Dir, fi.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return os.RemoveAll(f.Dir)
+}
+
+// wipeFile overwrites the contents of a file with zeros before removing it.
+func wipeFile(name string) error {
+	fd, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	fi, err := fd.Stat()
+	if err == nil {
+		_, err = fd.Write(make([]byte, fi.Size()))
+	}
+	if err == nil {
+		err = fd.Sync()
+	}
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(name)
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+func TestSecretFiles(t *testing.T) {
+	creds, _ := viewCredentialsHelper(t)
+
+	t.Run("writes selected secrets", func(t *testing.T) {
+		files, err := newSecretFiles([]string{"foo"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer files.Remove()
+
+		if runtime.GOOS != "windows" {
+			fi, err := os.Stat(files.Dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode().Perm() != 0700 {
+				t.Errorf("newSecretFiles() expected 0700 directory, got %s", fi.Mode().Perm())
+			}
+		}
+
+		err = files.Write(creds)
+		if err != nil {
+			t.Fatalf("Write() expected no errors, got %s", err)
+		}
+
+		entries, err := ioutil.ReadDir(files.Dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name() != "foo" {
+			t.Fatalf("Write() expected only foo to be written, got %v", entries)
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(files.Dir, "foo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "bar" {
+			t.Errorf("Write() expected contents %q, got %q", "bar", string(b))
+		}
+	})
+
+	t.Run("removes deleted secrets", func(t *testing.T) {
+		files, err := newSecretFiles([]string{"*"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer files.Remove()
+
+		err = files.Write(creds)
+		if err != nil {
+			t.Fatalf("Write() expected no errors, got %s", err)
+		}
+
+		err = files.Write(creds[:1])
+		if err != nil {
+			t.Fatalf("Write() expected no errors, got %s", err)
+		}
+
+		entries, err := ioutil.ReadDir(files.Dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name() != "foo" {
+			t.Errorf("Write() expected only foo to remain, got %v", entries)
+		}
+	})
+
+	t.Run("names are case insensitive", func(t *testing.T) {
+		files, err := newSecretFiles([]string{"FOO"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer files.Remove()
+
+		if err := files.Write(creds); err != nil {
+			t.Fatalf("Write() expected no errors, got %s", err)
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(files.Dir, "foo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "bar" {
+			t.Errorf("Write() expected contents %q, got %q", "bar", string(b))
+		}
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		files, err := newSecretFiles([]string{"nope"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer files.Remove()
+
+		if err := files.Write(creds); err == nil {
+			t.Error("Write() expected an error for a missing secret")
+		}
+	})
+
+	t.Run("unsafe names", func(t *testing.T) {
+		for _, name := range []string{"../escape", "a/b", `a\b`, ".hidden", "a..b"} {
+			var body apitypes.Credential = &apitypes.CredentialV2{
+				State: "set",
+				BaseCredential: apitypes.BaseCredential{
+					Name:  name,
+					Value: apitypes.NewStringCredentialValue("value"),
+				},
+			}
+
+			files, err := newSecretFiles([]string{"*"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = files.Write([]apitypes.CredentialEnvelope{{Body: &body}})
+			if err == nil {
+				t.Errorf("Write() expected an error for %q", name)
+			}
+
+			entries, _ := ioutil.ReadDir(files.Dir)
+			if len(entries) != 0 {
+				t.Errorf("Write() expected nothing to be written for %q, got %v", name, entries)
+			}
+
+			files.Remove()
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		files, err := newSecretFiles([]string{"*"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = files.Write(creds)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = files.Remove()
+		if err != nil {
+			t.Fatalf("Remove() expected no errors, got %s", err)
+		}
+
+		if _, err := os.Stat(files.Dir); !os.IsNotExist(err) {
+			t.Errorf("Remove() expected directory to be removed, got %v", err)
+		}
+	})
+}
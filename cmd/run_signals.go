@@ -0,0 +1,22 @@
+// +build !windows
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// stopSignal is sent to a watched command to stop it before restarting.
+var stopSignal os.Signal = syscall.SIGTERM
+
+// watchSignals are the signals which can be sent to a watched command when
+// its secrets change.
+var watchSignals = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
@@ -0,0 +1,11 @@
+package cmd
+
+import "os"
+
+// stopSignal is sent to a watched command to stop it before restarting.
+// Windows only supports killing a process.
+var stopSignal = os.Kill
+
+// watchSignals are the signals which can be sent to a watched command when
+// its secrets change. Windows does not support sending signals to processes.
+var watchSignals = map[string]os.Signal{}
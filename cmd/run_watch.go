@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+// stopTimeout is how long a command has to exit after being asked to stop,
+// before it is killed.
+const stopTimeout = 10 * time.Second
+
+// watchCmd runs the given command, checking for changes to its secrets every
+// interval. Once a change has settled for the debounce window, the command is
+// either restarted with the new secrets, or sent the configured signal.
+//
+// Changed secrets are renamed using the given aliases, as the initial secrets
+// were.
+//
+// The returned error is the result of waiting for the command to exit.
+func watchCmd(ctx *cli.Context, args []string, secrets []apitypes.CredentialEnvelope,
+	aliases map[string]string, path *pathexp.PathExp, files *secretFiles) error {
+	interval, err := time.ParseDuration(ctx.String("interval"))
+	if err != nil || interval <= 0 {
+		return errs.NewUsageExitError("Invalid interval: "+ctx.String("interval"), ctx)
+	}
+
+	debounce, err := time.ParseDuration(ctx.String("debounce"))
+	if err != nil || debounce < 0 {
+		return errs.NewUsageExitError("Invalid debounce: "+ctx.String("debounce"), ctx)
+	}
+
+	var sig os.Signal
+	if name := ctx.String("signal"); name != "" {
+		sig, err = parseSignal(name)
+		if err != nil {
+			return errs.NewUsageExitError(err.Error(), ctx)
+		}
+
+		// A running command's environment can't be changed, so signalling it
+		// is only useful if it reloads its secrets from files.
+		if files == nil {
+			return errs.NewUsageExitError("--signal requires --files, as the environment of "+
+				"a running command can't be updated", ctx)
+		}
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs) // give us all signals to relay
+	defer signal.Stop(sigs)
+
+	cmd, exited, err := startWatched(args, path, secrets, files)
+	if err != nil {
+		return errs.NewErrorExitError("Failed to run command", err)
+	}
+
+	current := secretsDigest(secrets)
+	var pending string
+	var pendingSecrets []apitypes.CredentialEnvelope
+	var settle <-chan time.Time
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case s := <-sigs:
+			cmd.Process.Signal(s)
+		case err := <-exited:
+			return err
+		case <-ticker.C:
+			if settle != nil {
+				continue
+			}
+
+			latest, _, err := fetchSecrets(ctx, nil)
+			if err != nil {
+				ui.Warn("Could not check for changed secrets: %s", err)
+				continue
+			}
+			latest = aliasSecrets(latest, aliases)
+
+			if d := secretsDigest(latest); d != current {
+				pending, pendingSecrets = d, latest
+				settle = time.After(debounce)
+			}
+		case <-settle:
+			settle = nil
+
+			// Make sure the secrets have stopped changing, so that updating
+			// several related secrets only restarts the command once.
+			latest, _, err := fetchSecrets(ctx, nil)
+			if err != nil {
+				ui.Warn("Could not check for changed secrets: %s", err)
+				continue
+			}
+			latest = aliasSecrets(latest, aliases)
+
+			d := secretsDigest(latest)
+			if d == current {
+				continue
+			}
+			if d != pending {
+				pending, pendingSecrets = d, latest
+				settle = time.After(debounce)
+				continue
+			}
+
+			if sig != nil {
+				if files != nil {
+					err = files.Write(pendingSecrets)
+					if err != nil {
+						ui.Warn("Could not update secret files: %s", err)
+						continue
+					}
+				}
+
+				current = pending
+				ui.Info("Secrets changed, sending %s to command", sig)
+				cmd.Process.Signal(sig)
+				continue
+			}
+
+			current = pending
+			ui.Info("Secrets changed, restarting command")
+			stopWatched(cmd, exited)
+
+			if files != nil {
+				err = files.Write(pendingSecrets)
+				if err != nil {
+					return errs.NewErrorExitError("Could not update secret files", err)
+				}
+			}
+
+			cmd, exited, err = startWatched(args, path, pendingSecrets, files)
+			if err != nil {
+				return errs.NewErrorExitError("Failed to restart command", err)
+			}
+		}
+	}
+}
+
+// startWatched starts the command, returning a channel that receives the
+// result of waiting on it.
+func startWatched(args []string, path *pathexp.PathExp, secrets []apitypes.CredentialEnvelope,
+	files *secretFiles) (*exec.Cmd, <-chan error, error) {
+	cmd := runCommand(args, path, secrets, files)
+	err := cmd.Start()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	return cmd, exited, nil
+}
+
+// stopWatched asks the command to stop, killing it if it hasn't exited after
+// stopTimeout.
+func stopWatched(cmd *exec.Cmd, exited <-chan error) {
+	cmd.Process.Signal(stopSignal)
+
+	select {
+	case <-exited:
+	case <-time.After(stopTimeout):
+		cmd.Process.Kill()
+		<-exited
+	}
+}
+
+// secretsDigest returns a digest of the names and values of the given secrets,
+// so they can be compared without holding on to their values.
+func secretsDigest(secrets []apitypes.CredentialEnvelope) string {
+	pairs := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		value := (*secret.Body).GetValue().String()
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+
+	h := sha256.New()
+	for _, p := range pairs {
+		fmt.Fprintf(h, "%d:%s", len(p), p)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// parseSignal returns the signal with the given name, which may optionally be
+// prefixed with SIG (e.g. HUP or SIGHUP).
+func parseSignal(name string) (os.Signal, error) {
+	sig, ok := watchSignals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return nil, fmt.Errorf("Unknown signal: %s", name)
+	}
+
+	return sig, nil
+}
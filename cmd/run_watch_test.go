@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+func TestSecretsDigest(t *testing.T) {
+	creds, _ := viewCredentialsHelper(t)
+	reversed := []apitypes.CredentialEnvelope{creds[1], creds[0]}
+
+	if secretsDigest(creds) != secretsDigest(reversed) {
+		t.Error("secretsDigest() expected digest to not depend on order")
+	}
+
+	if secretsDigest(creds) == secretsDigest(creds[:1]) {
+		t.Error("secretsDigest() expected digest to change when a secret is removed")
+	}
+
+	if secretsDigest(creds) == secretsDigest(typedCredentialsHelper()) {
+		t.Error("secretsDigest() expected different secrets to have different digests")
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	for name, sig := range watchSignals {
+		for _, in := range []string{name, "SIG" + name, "sig" + strings.ToLower(name)} {
+			got, err := parseSignal(in)
+			if err != nil {
+				t.Errorf("parseSignal(%q) expected no errors, got %s", in, err)
+			} else if got != sig {
+				t.Errorf("parseSignal(%q) expected %s, got %s", in, sig, got)
+			}
+		}
+	}
+
+	if _, err := parseSignal("SIGNOPE"); err == nil {
+		t.Error("parseSignal() expected an error for an unknown signal")
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -42,10 +43,11 @@ func init() {
 				Flags: []cli.Flag{
 					orgFlag("org to show services for", false),
 					projectFlag("project to show services for", false),
+					outputFlag,
 				},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					setUserEnv, checkRequiredFlags, listServicesCmd,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, setUserEnv, checkRequiredFlags, listServicesCmd,
 				),
 			},
 		},
@@ -55,6 +57,13 @@ func init() {
 
 const serviceListFailed = "Could not list services."
 
+// serviceOutput is the structured output of a service
+type serviceOutput struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Project string `json:"project"`
+}
+
 func listServicesCmd(ctx *cli.Context) error {
 
 	cfg, err := config.LoadConfig()
@@ -81,6 +90,14 @@ func listServicesCmd(ctx *cli.Context) error {
 		return errs.NewErrorExitError(serviceListFailed, err)
 	}
 
+	if structuredOutput(ctx) {
+		out := make([]serviceOutput, len(services))
+		for i, service := range services {
+			out[i] = serviceOutput{ID: service.ID.String(), Name: service.Body.Name, Project: project.Body.Name}
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	// Build output of projects/envs
 	fmt.Println("")
 	fmt.Printf("%s\n", ui.BoldString("Services"))
@@ -1,10 +1,18 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/urfave/cli"
 
@@ -14,6 +22,7 @@ import (
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/hints"
 	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
 )
 
 var setUnsetFlags = []cli.Flag{
@@ -31,7 +40,22 @@ func init() {
 		Usage:     "Set a secret for a service and environment",
 		ArgsUsage: "<name|path> <value> or <name|path>=<value>",
 		Category:  "SECRETS",
-		Flags:     setUnsetFlags,
+		Flags: append(setUnsetFlags,
+			newPlaceholder("type, t", "TYPE", "Type of the value, one of string, int, float, bool or json",
+				"string", "", false),
+			newPlaceholder("from-file", "PATH", "Read the value from a file", "", "", false),
+			cli.BoolFlag{
+				Name:  "stdin",
+				Usage: "Read the value from stdin",
+			},
+			newPlaceholder("description", "DESCRIPTION", "Describe what the secret is for", "", "", false),
+			newPlaceholder("owner", "TEAM", "Name of the team that owns the secret", "", "", false),
+			newSlicePlaceholder("tag", "TAG", "Tag the secret, can be supplied multiple times", "", "", false),
+			newPlaceholder("expires", "WHEN", "When the secret expires, either a date (2006-01-02), "+
+				"a time (RFC 3339), or a duration from now (90d, 12h)", "", "", false),
+			newSlicePlaceholder("clear", "FIELD", "Clear a metadata field ("+strings.Join(metadataFields, ", ")+
+				"), can be supplied multiple times", "", "", false),
+		),
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 			setSliceDefaults, setCmd,
@@ -42,9 +66,18 @@ func init() {
 }
 
 func setCmd(ctx *cli.Context) error {
-	args := ctx.Args()
-	key, value, err := parseSetArgs(args)
+	key, raw, err := readSetArgs(ctx)
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
 
+	value, err := parseTypedValue(ctx.String("type"), raw)
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	metadata, clear, err := parseMetadataFlags(ctx.String("description"), ctx.StringSlice("tag"),
+		ctx.String("expires"), ctx.String("owner") != "", ctx.StringSlice("clear"), time.Now())
 	if err != nil {
 		return errs.NewUsageExitError(err.Error(), ctx)
 	}
@@ -61,18 +94,21 @@ func setCmd(ctx *cli.Context) error {
 
 	makers := valueMakers{}
 	makers[name] = func() *apitypes.CredentialValue {
-		return apitypes.NewStringCredentialValue(value)
+		return value
 	}
 
 	s, p := spinner(fmt.Sprintf("Attempting to set credential %s", name))
 	s.Start()
-	_, err = setCredentials(ctx, path, makers, p)
+	_, err = setCredentialsWithMetadata(ctx, path, makers, metadata, clear, ctx.String("owner"), p)
 	s.Stop()
 	if err != nil {
 		return errs.NewErrorExitError("Could not set credential.", err)
 	}
 
 	fmt.Printf("\nCredential %s has been set at %s/%s\n", name, displayPathExp(path), name)
+	if value.IsBinary() {
+		fmt.Println("The value is binary, so it has been stored base64 encoded.")
+	}
 
 	hints.Display(hints.View, hints.Run, hints.Unset, hints.Import, hints.Export)
 	return nil
@@ -101,6 +137,209 @@ func parseSetArgs(args []string) (key string, value string, err error) {
 	return key, value, nil
 }
 
+// maxSecretSize is the largest value, in bytes, that can be set for a secret.
+const maxSecretSize = 64 * 1024
+
+// readSetArgs returns the secret name and its raw value, read from the
+// arguments, or from a file or stdin if --from-file or --stdin is supplied.
+func readSetArgs(ctx *cli.Context) (string, []byte, error) {
+	fromFile := ctx.String("from-file")
+	stdin := ctx.Bool("stdin")
+	if fromFile == "" && !stdin {
+		key, value, err := parseSetArgs(ctx.Args())
+		return key, []byte(value), err
+	}
+
+	if fromFile != "" && stdin {
+		return "", nil, errors.New("Only one of --from-file and --stdin can be supplied")
+	}
+
+	args := ctx.Args()
+	if len(args) != 1 || strings.Contains(args[0], "=") {
+		return "", nil, errors.New("Only a secret name can be supplied when reading the value from a file or stdin")
+	}
+
+	var r io.ReadCloser
+	var err error
+	if stdin {
+		r, err = openStdin()
+	} else {
+		r, err = openFile(fromFile)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	defer r.Close()
+
+	value, err := readSecretValue(r)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return strings.ToLower(args[0]), value, nil
+}
+
+// readSecretValue reads a secret's value, without reading more than is needed
+// to know it is too large.
+func readSecretValue(r io.Reader) ([]byte, error) {
+	value, err := ioutil.ReadAll(io.LimitReader(r, maxSecretSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("Error reading secret value. %s", err)
+	}
+
+	if len(value) == 0 {
+		return nil, errors.New("A secret must have a name and value")
+	}
+	if len(value) > maxSecretSize {
+		return nil, fmt.Errorf("Secret values cannot be larger than %d KiB", maxSecretSize/1024)
+	}
+
+	return value, nil
+}
+
+// parseTypedValue validates the raw value as the given type, and returns it as
+// a CredentialValue.
+//
+// Only strings and numbers can be stored, so bools and JSON are normalized
+// and stored as strings. Binary strings are stored as binary values, which are
+// base64 encoded, and decoded again when they're used.
+func parseTypedValue(typ string, raw []byte) (*apitypes.CredentialValue, error) {
+	if typ != "string" && !utf8.Valid(raw) {
+		return nil, fmt.Errorf("A %s value must be valid UTF-8 text", typ)
+	}
+
+	var value *apitypes.CredentialValue
+	switch typ {
+	case "string":
+		if isBinary(raw) {
+			value = apitypes.NewBinaryCredentialValue(raw)
+		} else {
+			value = apitypes.NewStringCredentialValue(string(raw))
+		}
+	case "int":
+		i, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid int", raw)
+		}
+		value = apitypes.NewIntCredentialValue(i)
+	case "float":
+		f, err := strconv.ParseFloat(strings.TrimSpace(string(raw)), 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("%q is not a valid float", raw)
+		}
+		value = apitypes.NewFloatCredentialValue(f)
+	case "bool":
+		b, err := strconv.ParseBool(strings.TrimSpace(string(raw)))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid bool", raw)
+		}
+		value = apitypes.NewStringCredentialValue(strconv.FormatBool(b))
+	case "json":
+		buf := &bytes.Buffer{}
+		if err := json.Compact(buf, raw); err != nil {
+			return nil, fmt.Errorf("Value is not valid JSON. %s", err)
+		}
+		value = apitypes.NewStringCredentialValue(buf.String())
+	default:
+		return nil, fmt.Errorf("Unknown type %q, expected one of string, int, float, bool or json", typ)
+	}
+
+	if len(value.String()) > maxSecretSize {
+		return nil, fmt.Errorf("Secret values cannot be larger than %d KiB", maxSecretSize/1024)
+	}
+
+	return value, nil
+}
+
+// metadataFields are the metadata fields which can be given to --clear
+var metadataFields = []string{
+	apitypes.MetadataDescription, apitypes.MetadataOwner, apitypes.MetadataTags, apitypes.MetadataExpires,
+}
+
+// parseMetadataFlags returns the metadata given by the --description, --tag,
+// and --expires flags, or nil if none of them, nor --owner, were supplied,
+// along with the fields given to --clear.
+func parseMetadataFlags(description string, tags []string, expires string, owner bool, clear []string,
+	now time.Time) (*primitive.CredentialMetadata, []string, error) {
+
+	given := map[string]bool{
+		apitypes.MetadataDescription: description != "",
+		apitypes.MetadataOwner:       owner,
+		apitypes.MetadataTags:        len(tags) > 0,
+		apitypes.MetadataExpires:     expires != "",
+	}
+	for _, f := range clear {
+		if !containsString(metadataFields, f) {
+			return nil, nil, fmt.Errorf("Unknown metadata field %q, must be one of %s", f,
+				strings.Join(metadataFields, ", "))
+		}
+		if given[f] {
+			return nil, nil, fmt.Errorf("The %s field cannot be both set and cleared", f)
+		}
+	}
+
+	if description == "" && len(tags) == 0 && expires == "" && !owner {
+		return nil, clear, nil
+	}
+
+	metadata := &primitive.CredentialMetadata{Description: description}
+
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || strings.ContainsAny(t, ", ") {
+			return nil, nil, fmt.Errorf("Invalid tag %q, tags cannot be empty or contain spaces or commas", t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			metadata.Tags = append(metadata.Tags, t)
+		}
+	}
+
+	if expires != "" {
+		at, err := parseExpiry(expires, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		metadata.ExpiresAt = &at
+	}
+
+	return metadata, clear, nil
+}
+
+// parseExpiry parses an expiry given as a date, an RFC 3339 time, or a
+// duration from now, which may be given in days (e.g. 90d).
+func parseExpiry(expires string, now time.Time) (time.Time, error) {
+	var at time.Time
+	var err error
+	if strings.HasSuffix(expires, "d") {
+		var days int
+		days, err = strconv.Atoi(strings.TrimSuffix(expires, "d"))
+		at = now.AddDate(0, 0, days)
+	} else if d, derr := time.ParseDuration(expires); derr == nil {
+		at = now.Add(d)
+	} else if at, err = time.Parse("2006-01-02", expires); err != nil {
+		at, err = time.Parse(time.RFC3339, expires)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid expiry %q, expected a date (2006-01-02), a time (RFC 3339), "+
+			"or a duration (90d, 12h)", expires)
+	}
+
+	if !at.After(now) {
+		return time.Time{}, fmt.Errorf("Expiry %q is not in the future", expires)
+	}
+
+	return at.UTC(), nil
+}
+
+// isBinary returns whether the value can't be stored as text, either because
+// it isn't UTF-8, or contains a NUL byte, which can't be in an environment
+// variable.
+func isBinary(value []byte) bool {
+	return !utf8.Valid(value) || bytes.IndexByte(value, 0) != -1
+}
+
 // determinePath returns a PathExp and a possible credential name if a full
 // path was provided.
 func determinePath(ctx *cli.Context, path string) (*pathexp.PathExp, *string, error) {
@@ -153,6 +392,20 @@ type valueMaker func() *apitypes.CredentialValue
 type valueMakers map[string]valueMaker
 
 func setCredentials(ctx *cli.Context, pe *pathexp.PathExp, makers valueMakers, p api.ProgressFunc) ([]apitypes.CredentialEnvelope, error) {
+	return setCredentialsWithMetadata(ctx, pe, makers, nil, nil, "", p)
+}
+
+// setCredentialsWithMetadata sets the credentials, describing them with the
+// given metadata, owned by the team with the given name. The metadata is
+// merged into that of each credential's previous version, after the fields
+// named in clear are removed from it.
+//
+// Credentials are only sent as v3, which can carry metadata, if the metadata
+// is changed; otherwise they're sent as v2.
+func setCredentialsWithMetadata(ctx *cli.Context, pe *pathexp.PathExp, makers valueMakers,
+	metadata *primitive.CredentialMetadata, clear []string, owner string,
+	p api.ProgressFunc) ([]apitypes.CredentialEnvelope, error) {
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return nil, err
@@ -173,6 +426,14 @@ func setCredentials(ctx *cli.Context, pe *pathexp.PathExp, makers valueMakers, p
 	}
 	project := projects[0]
 
+	if owner != "" {
+		teams, err := client.Teams.GetByName(c, org.ID, owner)
+		if len(teams) != 1 || err != nil {
+			return nil, errs.NewExitError("Team " + owner + " not found")
+		}
+		metadata.OwnerID = teams[0].ID
+	}
+
 	creds := []*apitypes.CredentialEnvelope{}
 	for name, maker := range makers {
 		value := maker()
@@ -182,7 +443,6 @@ func setCredentials(ctx *cli.Context, pe *pathexp.PathExp, makers valueMakers, p
 			value = nil
 		}
 
-		var cred apitypes.Credential
 		cBodyV2 := apitypes.CredentialV2{
 			BaseCredential: apitypes.BaseCredential{
 				OrgID:     org.ID,
@@ -193,10 +453,20 @@ func setCredentials(ctx *cli.Context, pe *pathexp.PathExp, makers valueMakers, p
 			},
 			State: state,
 		}
-		cred = &cBodyV2
+
+		var cred apitypes.Credential = &cBodyV2
+		var version uint8 = 2
+		if metadata != nil || len(clear) > 0 {
+			cred = &apitypes.CredentialV3{
+				CredentialV2:  cBodyV2,
+				Metadata:      metadata,
+				ClearMetadata: clear,
+			}
+			version = 3
+		}
 
 		creds = append(creds, &apitypes.CredentialEnvelope{
-			Version: 2,
+			Version: version,
 			Body:    &cred,
 		})
 	}
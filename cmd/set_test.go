@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseSetArgs(t *testing.T) {
@@ -51,3 +54,149 @@ func TestParseSetArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestParseTypedValue(t *testing.T) {
+	testCases := []struct {
+		typ   string
+		raw   string
+		value string
+		rawV  interface{}
+		err   bool
+	}{
+		{typ: "string", raw: "hello world", value: "hello world", rawV: "hello world"},
+		{typ: "string", raw: "-----BEGIN KEY-----\nabc\n-----END KEY-----\n",
+			value: "-----BEGIN KEY-----\nabc\n-----END KEY-----\n",
+			rawV:  "-----BEGIN KEY-----\nabc\n-----END KEY-----\n"},
+		{typ: "string", raw: "\x00\xff", value: "\x00\xff", rawV: "AP8="},
+		{typ: "string", raw: "a\x00b", value: "a\x00b", rawV: "YQBi"},
+		{typ: "int", raw: "5432\n", value: "5432", rawV: 5432},
+		{typ: "int", raw: "54.32", err: true},
+		{typ: "float", raw: "0.5", value: "0.5", rawV: 0.5},
+		{typ: "float", raw: "NaN", err: true},
+		{typ: "bool", raw: "TRUE", value: "true", rawV: "true"},
+		{typ: "bool", raw: "yes", err: true},
+		{typ: "json", raw: "{\n  \"a\": [1, 2]\n}", value: `{"a":[1,2]}`, rawV: `{"a":[1,2]}`},
+		{typ: "json", raw: "{a: 1}", err: true},
+		{typ: "int", raw: "\xff", err: true},
+		{typ: "yaml", raw: "a: 1", err: true},
+		{typ: "string", raw: strings.Repeat("a", maxSecretSize+1), err: true},
+	}
+
+	for _, tc := range testCases {
+		value, err := parseTypedValue(tc.typ, []byte(tc.raw))
+		if tc.err {
+			if err == nil {
+				t.Errorf("parseTypedValue(%s, %q) expected an error", tc.typ, tc.raw)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseTypedValue(%s, %q) expected no errors, got %q", tc.typ, tc.raw, err)
+			continue
+		}
+
+		rawV, _ := value.Raw()
+		if value.String() != tc.value || rawV != tc.rawV {
+			t.Errorf("parseTypedValue(%s, %q) expected %q (%#v), got %q (%#v)", tc.typ, tc.raw,
+				tc.value, tc.rawV, value.String(), rawV)
+		}
+	}
+}
+
+func TestReadSecretValue(t *testing.T) {
+	value, err := readSecretValue(strings.NewReader("secret\n"))
+	if err != nil || string(value) != "secret\n" {
+		t.Errorf("readSecretValue() expected %q, got %q (%v)", "secret\n", value, err)
+	}
+
+	if _, err := readSecretValue(strings.NewReader("")); err == nil {
+		t.Error("readSecretValue() expected an error for an empty value")
+	}
+
+	if _, err := readSecretValue(strings.NewReader(strings.Repeat("a", maxSecretSize+1))); err == nil {
+		t.Error("readSecretValue() expected an error for a value over the size limit")
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		expires string
+		at      time.Time
+		err     bool
+	}{
+		{expires: "90d", at: time.Date(2017, 8, 30, 12, 0, 0, 0, time.UTC)},
+		{expires: "12h", at: time.Date(2017, 6, 2, 0, 0, 0, 0, time.UTC)},
+		{expires: "2017-07-01", at: time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{expires: "2017-07-01T10:00:00-02:00", at: time.Date(2017, 7, 1, 12, 0, 0, 0, time.UTC)},
+		{expires: "2017-05-01", err: true},
+		{expires: "-1d", err: true},
+		{expires: "xd", err: true},
+		{expires: "next week", err: true},
+	}
+
+	for _, tc := range testCases {
+		at, err := parseExpiry(tc.expires, now)
+		if tc.err {
+			if err == nil {
+				t.Errorf("parseExpiry(%q) expected an error, got %s", tc.expires, at)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseExpiry(%q) expected no errors, got %q", tc.expires, err)
+		} else if !at.Equal(tc.at) {
+			t.Errorf("parseExpiry(%q) expected %s, got %s", tc.expires, tc.at, at)
+		}
+	}
+}
+
+func TestParseMetadataFlags(t *testing.T) {
+	now := time.Now()
+
+	m, clear, err := parseMetadataFlags("", nil, "", false, nil, now)
+	if m != nil || clear != nil || err != nil {
+		t.Errorf("parseMetadataFlags() expected no metadata, got %#v %v (%v)", m, clear, err)
+	}
+
+	m, _, err = parseMetadataFlags("", nil, "", true, nil, now)
+	if m == nil || err != nil {
+		t.Errorf("parseMetadataFlags() expected metadata for an owner, got %v", err)
+	}
+
+	m, _, err = parseMetadataFlags("Primary DB", []string{"DB", "prod", "db"}, "30d", false, nil, now)
+	if err != nil {
+		t.Fatalf("parseMetadataFlags() expected no errors, got %q", err)
+	}
+	if m.Description != "Primary DB" || !reflect.DeepEqual(m.Tags, []string{"db", "prod"}) || m.ExpiresAt == nil {
+		t.Errorf("parseMetadataFlags() got unexpected metadata %#v", m)
+	}
+
+	if _, _, err := parseMetadataFlags("", []string{"a,b"}, "", false, nil, now); err == nil {
+		t.Error("parseMetadataFlags() expected an error for an invalid tag")
+	}
+
+	m, clear, err = parseMetadataFlags("Primary DB", nil, "", false, []string{"tags", "expires"}, now)
+	if err != nil {
+		t.Fatalf("parseMetadataFlags() expected no errors, got %q", err)
+	}
+	if m.Description != "Primary DB" || !reflect.DeepEqual(clear, []string{"tags", "expires"}) {
+		t.Errorf("parseMetadataFlags() got unexpected metadata %#v, clearing %v", m, clear)
+	}
+
+	m, clear, err = parseMetadataFlags("", nil, "", false, []string{"owner"}, now)
+	if m != nil || !reflect.DeepEqual(clear, []string{"owner"}) || err != nil {
+		t.Errorf("parseMetadataFlags() expected only cleared fields, got %#v %v (%v)", m, clear, err)
+	}
+
+	if _, _, err := parseMetadataFlags("", nil, "", false, []string{"value"}, now); err == nil {
+		t.Error("parseMetadataFlags() expected an error for an unknown field")
+	}
+
+	if _, _, err := parseMetadataFlags("Primary DB", nil, "", false, []string{"description"}, now); err == nil {
+		t.Error("parseMetadataFlags() expected an error for a field both set and cleared")
+	}
+}
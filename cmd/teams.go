@@ -46,10 +46,11 @@ func init() {
 				Usage: "List teams in an organization",
 				Flags: []cli.Flag{
 					orgFlag("Use this organization.", false),
+					outputFlag,
 				},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					checkRequiredFlags, teamsListCmd,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, checkRequiredFlags, teamsListCmd,
 				),
 			},
 			{
@@ -58,10 +59,11 @@ func init() {
 				ArgsUsage: "<team>",
 				Flags: []cli.Flag{
 					orgFlag("Use this organization.", false),
+					outputFlag,
 				},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					checkRequiredFlags, teamMembersListCmd,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, checkRequiredFlags, teamMembersListCmd,
 				),
 			},
 			{
@@ -93,6 +95,14 @@ func init() {
 	Cmds = append(Cmds, teams)
 }
 
+// teamOutput is the structured output of a team
+type teamOutput struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Member bool   `json:"member"`
+}
+
 func teamsListCmd(ctx *cli.Context) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -149,20 +159,13 @@ func teamsListCmd(ctx *cli.Context) error {
 		)
 	}
 
-	numTeams := 0
-	fmt.Println("")
-	w := ansiterm.NewTabWriter(os.Stdout, 2, 0, 2, ' ', 0)
-	fmt.Fprintf(w, "\t%s\t%s\n", ui.BoldString("Team"), ui.BoldString("Type"))
+	out := []teamOutput{}
 	for _, t := range teams {
 		if isMachineTeam(t.Body) {
 			continue
 		}
 
-		numTeams++
-
-		isMember := ""
 		displayTeamType := ""
-
 		switch teamType := t.Body.TeamType; teamType {
 		case primitive.SystemTeamType:
 			displayTeamType = "system"
@@ -172,17 +175,34 @@ func teamsListCmd(ctx *cli.Context) error {
 			displayTeamType = "user"
 		}
 
-		if _, ok := memberOf[*t.ID]; ok {
+		out = append(out, teamOutput{
+			ID:     t.ID.String(),
+			Name:   t.Body.Name,
+			Type:   displayTeamType,
+			Member: memberOf[*t.ID],
+		})
+	}
+
+	if structuredOutput(ctx) {
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
+	fmt.Println("")
+	w := ansiterm.NewTabWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\t%s\t%s\n", ui.BoldString("Team"), ui.BoldString("Type"))
+	for _, t := range out {
+		isMember := ""
+		if t.Member {
 			isMember = ui.FaintString("*")
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\n", isMember, t.Body.Name, displayTeamType)
+		fmt.Fprintf(w, "%s\t%s\t%s\n", isMember, t.Name, t.Type)
 	}
 
 	w.Flush()
 
 	fmt.Printf("\nOrg %s has (%s) team%s\n", org.Body.Name,
-		ui.FaintString(strconv.Itoa(numTeams)), plural(numTeams))
+		ui.FaintString(strconv.Itoa(len(out))), plural(len(out)))
 
 	return nil
 }
@@ -241,6 +261,10 @@ func teamMembersListCmd(ctx *cli.Context) error {
 	}
 
 	if len(memberships) == 0 {
+		if structuredOutput(ctx) {
+			return writeOutput(ctx, os.Stdout, []memberOutput{})
+		}
+
 		fmt.Printf("%s has no members\n", team.Body.Name)
 		return nil
 	}
@@ -263,6 +287,18 @@ func teamMembersListCmd(ctx *cli.Context) error {
 		return errs.NewExitError("User not found.")
 	}
 
+	if structuredOutput(ctx) {
+		out := make([]memberOutput, len(profiles))
+		for i, profile := range profiles {
+			out[i] = memberOutput{
+				Name:     profile.Body.Name,
+				Username: profile.Body.Username,
+				Self:     session.Username() == profile.Body.Username,
+			}
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	fmt.Println("")
 	w := ansiterm.NewTabWriter(os.Stdout, 2, 0, 2, ' ', 0)
 	fmt.Fprintf(w, "\t%s\t%s\n", ui.BoldString("Name"), ui.BoldString("Username"))
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+func init() {
+	tmpl := cli.Command{
+		Name:      "template",
+		Usage:     "Render a template file with secrets for a specific environment and service",
+		ArgsUsage: "<input file> <output file>",
+		Category:  "SECRETS",
+		Flags: []cli.Flag{
+			stdOrgFlag,
+			stdProjectFlag,
+			stdEnvFlag,
+			serviceFlag("Use this service.", "default", true),
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setUserEnv, checkRequiredFlags, templateCmd,
+		),
+	}
+
+	Cmds = append(Cmds, tmpl)
+}
+
+func templateCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 2 {
+		return errs.NewUsageExitError("An input and output file must be supplied.", ctx)
+	}
+
+	in, out := args[0], args[1]
+
+	contents, err := ioutil.ReadFile(in)
+	if err != nil {
+		return errs.NewErrorExitError("Could not read template", err)
+	}
+
+	secrets, _, err := getSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Render the whole template before touching the output file, so a
+	// failure doesn't leave a partially written config behind.
+	var buf bytes.Buffer
+	err = renderTemplate(&buf, filepath.Base(in), string(contents), secrets)
+	if err != nil {
+		return errs.NewErrorExitError("Could not render template", err)
+	}
+
+	fd, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errs.NewErrorExitError("Could not write to given filepath", err)
+	}
+	defer fd.Close()
+
+	// OpenFile only applies the mode to new files; tighten existing ones too.
+	err = fd.Chmod(0600)
+	if err != nil {
+		return errs.NewErrorExitError("Could not set permissions on given filepath", err)
+	}
+
+	_, err = buf.WriteTo(fd)
+	if err != nil {
+		return errs.NewErrorExitError("Could not write to given filepath", err)
+	}
+
+	return nil
+}
+
+// renderTemplate renders the text/template src to w.
+//
+// Secrets are available to the template via the `secret` function, and as
+// fields of the template's data (e.g. `{{ .db_password }}`). Referencing a
+// secret that does not exist is an error.
+func renderTemplate(w io.Writer, name, src string, secrets []apitypes.CredentialEnvelope) error {
+	values := make(map[string]interface{})
+	for _, secret := range secrets {
+		value := (*secret.Body).GetValue()
+		v, err := value.Raw()
+		if err != nil {
+			return err
+		}
+
+		// Binary values are decoded, so they can be encoded with b64enc.
+		if value.IsBinary() {
+			v = value.String()
+		}
+
+		values[(*secret.Body).GetName()] = v
+	}
+
+	t, err := template.New(name).Option("missingkey=error").Funcs(templateFuncs(values)).Parse(src)
+	if err != nil {
+		return err
+	}
+
+	return t.Execute(w, values)
+}
+
+func templateFuncs(values map[string]interface{}) template.FuncMap {
+	return template.FuncMap{
+		"secret": func(name string) (interface{}, error) {
+			v, ok := values[strings.ToLower(name)]
+			if !ok {
+				return nil, fmt.Errorf("secret %q not found", name)
+			}
+
+			return v, nil
+		},
+		"default": func(def, v interface{}) interface{} {
+			if v == nil || fmt.Sprint(v) == "" {
+				return def
+			}
+
+			return v
+		},
+		"b64enc": func(v interface{}) string {
+			return base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(v)))
+		},
+		"toJSON": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+}
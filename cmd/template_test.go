@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	creds, _ := viewCredentialsHelper(t)
+	creds = append(creds, typedCredentialsHelper()...)
+
+	tcs := []struct {
+		name     string
+		src      string
+		expected string
+		err      string
+	}{
+		{"secret", `pass={{ secret "foo" }}`, "pass=bar", ""},
+		{"upper case secret", `pass={{ secret "FOO" }}`, "pass=bar", ""},
+		{"field", `{{ .baz }}`, "two words", ""},
+		{"b64enc", `{{ secret "foo" | b64enc }}`, "YmFy", ""},
+		{"toJSON string", `{{ secret "baz" | toJSON }}`, `"two words"`, ""},
+		{"toJSON number", `{{ secret "port" | toJSON }}`, "5432", ""},
+		{"default unused", `{{ secret "foo" | default "x" }}`, "bar", ""},
+		{"missing secret", `{{ secret "nope" }}`, "", `secret "nope" not found`},
+		{"missing field", `{{ .nope }}`, "", `map has no entry for key "nope"`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := renderTemplate(&buf, "test", tc.src, creds)
+
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("renderTemplate() expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("renderTemplate() expected no errors, got %s", err)
+			}
+
+			if got := buf.String(); got != tc.expected {
+				t.Errorf("renderTemplate() expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
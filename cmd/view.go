@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/juju/ansiterm"
 	"github.com/urfave/cli"
@@ -14,6 +15,7 @@ import (
 	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/hints"
+	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/pathexp"
 	"github.com/manifoldco/torus-cli/ui"
 )
@@ -30,11 +32,12 @@ func init() {
 			serviceFlag("Use this service.", "default", true),
 			cli.BoolFlag{
 				Name:  "verbose, v",
-				Usage: "Lists the sources of the secrets (shortcut for --format verbose)",
+				Usage: "Lists the sources and metadata of the secrets (shortcut for --format verbose)",
 			},
+			outputFlag,
 		},
 		Action: chain(
-			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 			setUserEnv, checkRequiredFlags, viewCmd,
 		),
 	}
@@ -49,14 +52,40 @@ func viewCmd(ctx *cli.Context) error {
 	}
 
 	verbose := ctx.Bool("verbose")
+	structured := structuredOutput(ctx)
+
+	var owners map[identity.ID]string
+	if verbose || structured {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+
+		owners, err = credentialOwners(context.Background(), api.NewClient(cfg), secrets)
+		if err != nil {
+			return errs.NewErrorExitError("Could not retrieve secret owners.", err)
+		}
+	}
 
 	w := os.Stdout
+	now := time.Now()
+
+	if structured {
+		out := make([]secretOutput, len(secrets))
+		for i, secret := range secrets {
+			out[i] = newSecretOutput(secret, owners, now)
+		}
+		return writeOutput(ctx, w, out)
+	}
 
 	fmt.Fprintf(w, "Credential path: %s\n\n", displayPathExp(path))
 
 	tw := ansiterm.NewTabWriter(w, 2, 0, 2, ' ', 0)
 	for _, secret := range secrets {
 		value := (*secret.Body).GetValue().String()
+		if (*secret.Body).GetValue().IsBinary() {
+			value = fmt.Sprintf("<binary, %d bytes>", len(value))
+		}
 		name := (*secret.Body).GetName()
 		spath := displayPathExp((*secret.Body).GetPathExp()) + "/" + name
 
@@ -66,6 +95,9 @@ func viewCmd(ctx *cli.Context) error {
 			} else {
 				fmt.Fprintf(tw, "%s\t=\t%s\t(%s)\n", ui.BoldString(name), value, ui.FaintString(spath))
 			}
+			if m := formatCredentialMetadata((*secret.Body).GetMetadata(), owners, now); m != "" {
+				fmt.Fprintf(tw, "\t\t%s\t\n", m)
+			}
 		} else {
 			if strings.Contains(value, " ") {
 				fmt.Fprintf(tw, "%s\t=\t%q\n", ui.BoldString(name), value)
@@ -83,6 +115,18 @@ func viewCmd(ctx *cli.Context) error {
 }
 
 func getSecrets(ctx *cli.Context) ([]apitypes.CredentialEnvelope, *pathexp.PathExp, error) {
+	s, p := spinner("Decrypting credentials")
+	s.Start()
+	defer s.Stop()
+
+	return fetchSecrets(ctx, p)
+}
+
+// fetchSecrets retrieves and compacts the secrets for the org, project,
+// environment and service given in ctx, resolving references within their
+// values. An error listing each secret whose references can't be resolved is
+// returned if any can't be. Progress is reported to p, which may be nil.
+func fetchSecrets(ctx *cli.Context, p api.ProgressFunc) ([]apitypes.CredentialEnvelope, *pathexp.PathExp, error) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return nil, nil, err
@@ -103,20 +147,21 @@ func getSecrets(ctx *cli.Context) ([]apitypes.CredentialEnvelope, *pathexp.PathE
 		return nil, nil, errs.NewErrorExitError("Error deriving credential path", err)
 	}
 
-	s, p := spinner("Decrypting credentials")
-	s.Start()
-	defer s.Stop()
-	secrets, err := client.Credentials.Get(c, path.String(), p)
+	secrets, err := fetchCompactedSecrets(c, client, path, true, p)
 	if err != nil {
-		return nil, nil, errs.NewErrorExitError("Error fetching secrets", err)
+		return nil, nil, err
 	}
 
-	cset := credentialSet{}
-	for _, c := range secrets {
-		if err := cset.Add(c); err != nil {
-			return nil, nil, errs.NewErrorExitError("Error compacting secrets", err)
+	unresolved := []string{}
+	for _, s := range secrets {
+		if s.ReferenceError != "" {
+			unresolved = append(unresolved, fmt.Sprintf("  %s: %s", (*s.Body).GetName(), s.ReferenceError))
 		}
 	}
+	if len(unresolved) > 0 {
+		return nil, nil, errs.NewExitError("Could not resolve references in secrets:\n" +
+			strings.Join(unresolved, "\n"))
+	}
 
 	out, err := pathexp.New(ctx.String("org"), ctx.String("project"),
 		[]string{ctx.String("environment")}, []string{ctx.String("service")},
@@ -125,7 +170,27 @@ func getSecrets(ctx *cli.Context) ([]apitypes.CredentialEnvelope, *pathexp.PathE
 		return nil, nil, err
 	}
 
-	return cset.ToSlice(), out, nil
+	return secrets, out, nil
+}
+
+// fetchCompactedSecrets retrieves the secrets for the given explicit path,
+// compacting them so only the most specific value for each name remains.
+func fetchCompactedSecrets(c context.Context, client *api.Client, path *pathexp.PathExp,
+	resolveReferences bool, p api.ProgressFunc) ([]apitypes.CredentialEnvelope, error) {
+
+	secrets, err := client.Credentials.Get(c, path.String(), resolveReferences, p)
+	if err != nil {
+		return nil, errs.NewErrorExitError("Error fetching secrets", err)
+	}
+
+	cset := credentialSet{}
+	for _, c := range secrets {
+		if err := cset.Add(c); err != nil {
+			return nil, errs.NewErrorExitError("Error compacting secrets", err)
+		}
+	}
+
+	return cset.ToSlice(), nil
 }
 
 func deriveExplicitPathExp(org, project, env, service, identity string) (*pathexp.PathExp, error) {
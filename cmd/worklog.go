@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/manifoldco/promptui"
 	"github.com/urfave/cli"
@@ -12,6 +14,8 @@ import (
 	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/prefs"
 	"github.com/manifoldco/torus-cli/primitive"
 	"github.com/manifoldco/torus-cli/prompts"
 	"github.com/manifoldco/torus-cli/ui"
@@ -23,6 +27,7 @@ var catOrder = []apitypes.WorklogType{
 	apitypes.UserKeyringMembersWorklogType,
 	apitypes.MachineKeyringMembersWorklogType,
 	apitypes.SecretRotateWorklogType,
+	apitypes.SecretStaleWorklogType,
 }
 
 var (
@@ -42,27 +47,33 @@ func init() {
 			{
 				Name:  "list",
 				Usage: "List worklog maintenance tasks",
-				Flags: []cli.Flag{stdOrgFlag},
+				Flags: []cli.Flag{stdOrgFlag, outputFlag},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					checkRequiredFlags, worklogList,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, checkRequiredFlags, worklogList,
 				),
 			},
 			{
 				Name:      "view",
 				Usage:     "Show the details of a worklog item",
 				ArgsUsage: "<identity>",
-				Flags:     []cli.Flag{stdOrgFlag},
+				Flags:     []cli.Flag{stdOrgFlag, outputFlag},
 				Action: chain(
-					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-					checkRequiredFlags, worklogView,
+					checkOutputFormat, ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, checkRequiredFlags, worklogView,
 				),
 			},
 			{
 				Name:      "resolve",
 				Usage:     "Act on and resolve the given worklog items",
 				ArgsUsage: "[identity...]",
-				Flags:     []cli.Flag{stdOrgFlag},
+				Flags: []cli.Flag{
+					stdOrgFlag,
+					cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show how the items would be resolved, without resolving them",
+					},
+				},
 				Action: chain(
 					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 					checkRequiredFlags, worklogResolve,
@@ -85,6 +96,8 @@ func groupMsgFor(typ apitypes.WorklogType) string {
 		return "Machines missing granted access to secrets in the %s org:"
 	case apitypes.SecretRotateWorklogType:
 		return "Secrets that should be rotated in the %s org:"
+	case apitypes.SecretStaleWorklogType:
+		return "Secrets older than their maximum age in the %s org:"
 	default:
 		return ""
 	}
@@ -100,6 +113,8 @@ func subjectFor(item *apitypes.WorklogItem) string {
 		return underline(d.Name)
 	case *apitypes.SecretRotateWorklogDetails:
 		return item.Subject()
+	case *apitypes.SecretStaleWorklogDetails:
+		return item.Subject()
 	default:
 		return item.Subject()
 	}
@@ -140,11 +155,34 @@ func detailsFor(org *envelope.Org, item *apitypes.WorklogItem) {
 
 			c.LineIndent(2, "%s %s", underline(r.Username), rm)
 		}
+	case *apitypes.SecretStaleWorklogDetails:
+		u.Line("The value for this secret should be rotated. It was set on %s, and secrets in %s "+
+			"should be rotated every %s.", d.SetAt.Local().Format("2006-01-02"), underline(d.MaxAgePathExp.String()),
+			formatMaxAge(d.MaxAge))
 	default:
 		u.Line(item.Subject())
 	}
 }
 
+// worklogOutput is the structured output of a worklog item
+type worklogOutput struct {
+	ID      string                  `json:"id"`
+	Type    string                  `json:"type"`
+	Subject string                  `json:"subject"`
+	Summary string                  `json:"summary"`
+	Details apitypes.WorklogDetails `json:"details"`
+}
+
+func newWorklogOutput(item *apitypes.WorklogItem) worklogOutput {
+	return worklogOutput{
+		ID:      item.ID.String(),
+		Type:    item.Type().String(),
+		Subject: item.Subject(),
+		Summary: item.Summary(),
+		Details: item.Details,
+	}
+}
+
 func worklogList(ctx *cli.Context) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -164,6 +202,14 @@ func worklogList(ctx *cli.Context) error {
 		return errs.NewErrorExitError("Could not retrieve worklog items", err)
 	}
 
+	if structuredOutput(ctx) {
+		out := make([]worklogOutput, len(items))
+		for i := range items {
+			out[i] = newWorklogOutput(&items[i])
+		}
+		return writeOutput(ctx, os.Stdout, out)
+	}
+
 	if len(items) == 0 {
 		ui.Line("Worklog complete! No items left to resolve. 👍")
 		return nil
@@ -231,6 +277,10 @@ func worklogView(ctx *cli.Context) error {
 		return errs.NewErrorExitError("Could not retrieve worklog item", err)
 	}
 
+	if structuredOutput(ctx) {
+		return writeOutput(ctx, os.Stdout, newWorklogOutput(item))
+	}
+
 	ui.Line("%s %s\n", yellow(item.ID.String()), subjectFor(item))
 	detailsFor(org, item)
 	return nil
@@ -250,6 +300,21 @@ func worklogResolve(ctx *cli.Context) error {
 		return err
 	}
 
+	// Rotations are only loaded once a secret needs rotating, so a problem
+	// with them doesn't prevent resolving other items.
+	var rotations []prefs.SecretRotation
+	var rotationsErr error
+	rotationsLoaded := false
+	loadRotations := func() ([]prefs.SecretRotation, error) {
+		if !rotationsLoaded {
+			rotations, rotationsErr = prefs.LoadSecretRotations()
+			rotationsLoaded = true
+		}
+		return rotations, rotationsErr
+	}
+
+	dryRun := ctx.Bool("dry-run")
+
 	var idents []apitypes.WorklogID
 	for _, raw := range ctx.Args() {
 		ident, err := apitypes.DecodeWorklogIDFromString(raw)
@@ -308,6 +373,37 @@ func worklogResolve(ctx *cli.Context) error {
 		}
 
 		for _, item := range items {
+			if pe, name, ok := rotationSubject(&item); ok {
+				rotations, err := loadRotations()
+				if err != nil {
+					displayResult(&item, fmt.Errorf("Could not load secret rotations: %s", err), grouped)
+					continue
+				}
+
+				r := findRotation(rotations, pe, name)
+				switch {
+				case r == nil:
+					displayResult(&item, nil, grouped)
+				case dryRun:
+					displayLine(&item, promptui.IconGood, fmt.Sprintf("Would rotate %s with %q",
+						subjectFor(&item), r.String()), grouped)
+				default:
+					err := rotateSecret(ctx, client, r, pe, name)
+					if err != nil {
+						displayResult(&item, err, grouped)
+					} else {
+						displayLine(&item, promptui.IconGood, fmt.Sprintf("Secret %s has been rotated",
+							subjectFor(&item)), grouped)
+					}
+				}
+				continue
+			}
+
+			if dryRun {
+				displayLine(&item, promptui.IconGood, fmt.Sprintf("Would resolve %s", subjectFor(&item)), grouped)
+				continue
+			}
+
 			// An explicit invite id won't trigger a prompt
 			if item.Type() == apitypes.InviteApproveWorklogType && grouped {
 				msg := fmt.Sprintf("%s%s Approve invite for %s", promptui.ResetCode,
@@ -319,9 +415,6 @@ func worklogResolve(ctx *cli.Context) error {
 				if !success {
 					continue // skip it!
 				}
-			} else if item.Type() == apitypes.SecretRotateWorklogType {
-				displayResult(&item, nil, grouped)
-				continue
 			}
 
 			err := client.Worklog.Resolve(c, org.ID, item.ID)
@@ -335,18 +428,10 @@ func worklogResolve(ctx *cli.Context) error {
 func displayResult(item *apitypes.WorklogItem, err error, grouped bool) {
 	icon := promptui.IconGood
 
-	if item.Type() == apitypes.SecretRotateWorklogType {
+	if item.Type() == apitypes.SecretRotateWorklogType || item.Type() == apitypes.SecretStaleWorklogType {
 		icon = promptui.IconWarn
 	}
 
-	indent := 0
-	idFmt := yellow
-
-	if grouped {
-		indent = 2
-		idFmt = faint
-	}
-
 	var message string
 	if err != nil {
 		icon = promptui.IconBad
@@ -362,7 +447,9 @@ func displayResult(item *apitypes.WorklogItem, err error, grouped bool) {
 		case apitypes.MachineKeyringMembersWorklogType:
 			typ = "reconciling secret access"
 		case apitypes.SecretRotateWorklogType:
-			typ = "rotating secret" // this one will never happen; its manual.
+			fallthrough
+		case apitypes.SecretStaleWorklogType:
+			typ = "rotating secret"
 		}
 
 		message = fmt.Sprintf("Error %s: %s", typ, err)
@@ -377,12 +464,51 @@ func displayResult(item *apitypes.WorklogItem, err error, grouped bool) {
 		case apitypes.MachineKeyringMembersWorklogType:
 			message = "Secret access for machine %s has been reconciled."
 		case apitypes.SecretRotateWorklogType:
+			fallthrough
+		case apitypes.SecretStaleWorklogType:
 			message = "Please set a new value for %s"
 		}
 
 		message = fmt.Sprintf(message, subjectFor(item))
 	}
 
+	displayLine(item, icon, message, grouped)
+}
+
+func displayLine(item *apitypes.WorklogItem, icon, message string, grouped bool) {
+	indent := 0
+	idFmt := yellow
+
+	if grouped {
+		indent = 2
+		idFmt = faint
+	}
+
 	u := ui.Child(indent)
 	u.LineIndent(4, "%s %s %s", icon, idFmt(item.ID.String()), message)
 }
+
+// rotationSubject returns the path and name of the secret to rotate for
+// items that require a secret be rotated.
+func rotationSubject(item *apitypes.WorklogItem) (*pathexp.PathExp, string, bool) {
+	switch d := item.Details.(type) {
+	case *apitypes.SecretRotateWorklogDetails:
+		return d.PathExp, d.Name, true
+	case *apitypes.SecretStaleWorklogDetails:
+		return d.PathExp, d.Name, true
+	default:
+		return nil, "", false
+	}
+}
+
+// formatMaxAge returns the max age in days, or as a duration if it isn't a
+// whole number of days.
+func formatMaxAge(d time.Duration) string {
+	day := 24 * time.Hour
+	if d%day != 0 {
+		return d.String()
+	}
+
+	days := int(d / day)
+	return fmt.Sprintf("%d day%s", days, plural(days))
+}
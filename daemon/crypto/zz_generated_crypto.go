@@ -146,6 +146,23 @@ func (e *Engine) SignedCredentialV1(ctx context.Context, body *primitive.Credent
 	}, nil
 }
 
+// SignedCredentialV2 returns a CredentialV2Envelope that is signed, and includes
+// a tamper-proof ID.
+func (e *Engine) SignedCredentialV2(ctx context.Context, body *primitive.CredentialV2,
+	sigID *identity.ID, sigKP *SignatureKeyPair) (*envelope.CredentialV2, error) {
+	id, sig, err := e.signAndID(ctx, body, sigID, sigKP)
+	if err != nil {
+		return nil, err
+	}
+
+	return &envelope.CredentialV2{
+		ID:        id,
+		Version:   uint8(body.Version()),
+		Body:      body,
+		Signature: *sig,
+	}, nil
+}
+
 // SignedCredential returns a CredentialEnvelope that is signed, and includes
 // a tamper-proof ID.
 func (e *Engine) SignedCredential(ctx context.Context, body *primitive.Credential,
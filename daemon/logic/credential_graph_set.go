@@ -210,6 +210,32 @@ func (cgs *credentialGraphSet) NeedRotation() ([]RotationReason, error) {
 	return needRotation, nil
 }
 
+// ActiveCredentials returns the active Credentials in all versions of all
+// CredentialGraphs, along with the CredentialGraph that contains each.
+func (cgs *credentialGraphSet) ActiveCredentials() ([]credentialVersion, error) {
+	var active []credentialVersion
+
+	for _, graphs := range cgs.graphs {
+		var parents []identity.ID
+
+		sort.Sort(graphSorter(graphs))
+		for _, graph := range graphs {
+			var activeCreds []envelope.CredentialInf
+			var err error
+			activeCreds, parents, err = cgs.activeCreds(parents, graph)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, c := range activeCreds {
+				active = append(active, credentialVersion{Credential: c, Graph: graph})
+			}
+		}
+	}
+
+	return active, nil
+}
+
 // Head returns the most recent version of a CredentialGraph that would contain
 // the given PathExp.
 func (cgs *credentialGraphSet) Head(pe *pathexp.PathExp) (registry.CredentialGraph, error) {
@@ -261,6 +287,50 @@ func (cgs *credentialGraphSet) HeadCredential(pe *pathexp.PathExp, name string)
 	return head, nil
 }
 
+// credentialVersion is a single version of a Credential, along with the
+// CredentialGraph that contains it.
+type credentialVersion struct {
+	Credential envelope.CredentialInf
+	Graph      registry.CredentialGraph
+}
+
+// History returns every version of the Credential that shares the provided
+// PathExp and Name, starting with the HeadCredential and following each
+// version's Previous link. Unlike Prune, unset versions are included.
+func (cgs *credentialGraphSet) History(pe *pathexp.PathExp, name string) ([]credentialVersion, error) {
+	head, err := cgs.HeadCredential(pe, name)
+	if err != nil || head == nil {
+		return nil, err
+	}
+
+	gpe, err := pe.WithInstance("*")
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[identity.ID]credentialVersion)
+	for _, graph := range cgs.graphs[gpe.String()] {
+		for _, cred := range graph.GetCredentials() {
+			byID[*cred.GetID()] = credentialVersion{Credential: cred, Graph: graph}
+		}
+	}
+
+	var history []credentialVersion
+	seen := make(map[identity.ID]bool)
+	for id := head.GetID(); id != nil && !seen[*id]; {
+		version, ok := byID[*id]
+		if !ok {
+			break
+		}
+
+		seen[*id] = true
+		history = append(history, version)
+		id = version.Credential.Previous()
+	}
+
+	return history, nil
+}
+
 // graphSorter implements sort.Interface, for sorting CredentialGraphs
 // by version in decreasing order
 type graphSorter []registry.CredentialGraph
@@ -19,11 +19,12 @@ var (
 )
 
 type cred struct {
-	id    *identity.ID
-	prev  *identity.ID
-	state *string
-	pe    *string
-	name  *string
+	id      *identity.ID
+	prev    *identity.ID
+	state   *string
+	pe      *string
+	name    *string
+	version int
 }
 
 func mustID(raw string) *identity.ID {
@@ -62,7 +63,8 @@ func buildGraph(rawPathExp string, version int, secrets ...cred) registry.Creden
 
 	for _, secret := range secrets {
 		base := primitive.BaseCredential{
-			Previous: secret.prev,
+			Previous:          secret.prev,
+			CredentialVersion: secret.version,
 		}
 
 		if secret.pe != nil {
@@ -673,3 +675,55 @@ func TestCredentialGraphSetNeedRotation(t *testing.T) {
 		}
 	})
 }
+
+func TestCredentialGraphSetHistory(t *testing.T) {
+	pe := "/o/p/e/s/u/i"
+	name := "secret"
+	other := "other"
+
+	t.Run("Across versions", func(t *testing.T) {
+		cgs := newCredentialGraphSet()
+
+		cgs.Add(buildGraph("/o/p/e/s/u/*", 1,
+			cred{id: id1, pe: &pe, name: &name, version: 1}))
+		cgs.Add(buildGraph("/o/p/e/s/u/*", 2,
+			cred{id: id2, prev: id1, pe: &pe, name: &name, version: 2, state: &unset},
+			cred{id: mustID("04100000000000000000000001000"), pe: &pe, name: &other, version: 1}))
+		cgs.Add(buildGraph("/o/p/e/s/u/*", 3,
+			cred{id: id3, prev: id2, pe: &pe, name: &name, version: 3}))
+
+		history, err := cgs.History(mustPathExp(pe), name)
+		if err != nil {
+			t.Fatal("error seen:", err)
+		}
+
+		want := []*identity.ID{id3, id2, id1}
+		if len(history) != len(want) {
+			t.Fatal("Wrong history length. wanted:", len(want), "got:", len(history))
+		}
+
+		for i, id := range want {
+			if *history[i].Credential.GetID() != *id {
+				t.Error("Wrong credential at", i, "wanted:", id, "got:", history[i].Credential.GetID())
+			}
+		}
+
+		if v := history[2].Graph.KeyringVersion(); v != 1 {
+			t.Error("Wrong keyring version. wanted: 1 got:", v)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		cgs := newCredentialGraphSet()
+		cgs.Add(buildGraph("/o/p/e/s/u/*", 1, cred{id: id1, pe: &pe, name: &other, version: 1}))
+
+		history, err := cgs.History(mustPathExp(pe), name)
+		if err != nil {
+			t.Fatal("error seen:", err)
+		}
+
+		if len(history) != 0 {
+			t.Error("Expected no history. got:", len(history))
+		}
+	})
+}
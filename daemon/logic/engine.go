@@ -5,16 +5,16 @@ package logic
 
 import (
 	"context"
-	"encoding/json"
 	"log"
-	"strconv"
 	"sync"
+	"time"
 
 	"github.com/manifoldco/go-base64"
 
 	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
 	"github.com/manifoldco/torus-cli/primitive"
 	"github.com/manifoldco/torus-cli/registry"
 
@@ -166,7 +166,9 @@ func (e *Engine) AppendCredentials(ctx context.Context, notifier *observer.Notif
 
 		// Construct an encrypted and signed version of the credential
 		credBody := primitive.Credential{
-			State: c.Body.State,
+			Created:  time.Now().UTC(),
+			State:    c.Body.State,
+			Metadata: c.Body.Metadata,
 			BaseCredential: primitive.BaseCredential{
 				Name:      c.Body.Name,
 				PathExp:   c.Body.PathExp,
@@ -188,6 +190,20 @@ func (e *Engine) AppendCredentials(ctx context.Context, notifier *observer.Notif
 			credBody.CredentialVersion = previousCred.CredentialVersion() + 1
 		}
 
+		// Metadata is merged into that of the previous version, unless the
+		// credential is being unset.
+		if c.Body.State != nil && *c.Body.State == "unset" {
+			credBody.Metadata = nil
+		} else {
+			var previous *primitive.CredentialMetadata
+			if previousCred != nil && !previousCred.Unset() {
+				previous = previousCred.Metadata()
+			}
+			credBody.Metadata = mergeCredentialMetadata(previous, c.Body.Metadata, c.Body.ClearMetadata)
+		}
+		c.Body.Metadata = credBody.Metadata
+		c.Body.ClearMetadata = nil
+
 		// Derive a key for the credential using the keyring master key
 		// and use the derived key to encrypt the credential
 		cekNonce, ctNonce, ct, err := e.crypto.BoxCredential(
@@ -203,11 +219,22 @@ func (e *Engine) AppendCredentials(ctx context.Context, notifier *observer.Notif
 		credBody.Credential.Nonce = base64.New(ctNonce)
 		credBody.Credential.Value = base64.New(ct)
 
-		signed, err := e.crypto.SignedCredential(ctx, &credBody, sigID, &kp.Signature)
+		// Credentials without metadata are stored as v2, as they were
+		// before metadata was added.
+		var signed envelope.CredentialInf
+		if credBody.Metadata == nil {
+			signed, err = e.crypto.SignedCredentialV2(ctx, &primitive.CredentialV2{
+				BaseCredential: credBody.BaseCredential,
+				State:          credBody.State,
+			}, sigID, &kp.Signature)
+		} else {
+			signed, err = e.crypto.SignedCredential(ctx, &credBody, sigID, &kp.Signature)
+		}
 		if err != nil {
 			log.Printf("Error signing credential body: %s", err)
 			return nil, err
 		}
+		c.Version = signed.GetVersion()
 
 		toCreate = append(toCreate, signed)
 		n.Notify(observer.Progress, "Credential encrypted", true)
@@ -229,9 +256,13 @@ func (e *Engine) AppendCredentials(ctx context.Context, notifier *observer.Notif
 	return creds, nil
 }
 
-// RetrieveCredentials returns all credentials for the given CPath string
-func (e *Engine) RetrieveCredentials(ctx context.Context,
-	notifier *observer.Notifier, cpath, cpathexp *string, teamIDs []identity.ID, skipDecryption bool) ([]PlaintextCredentialEnvelope, error) {
+// RetrieveCredentials returns all credentials for the given CPath string.
+//
+// If resolve is set, references within the decrypted values are resolved. A
+// credential whose references can't be resolved keeps its raw value, and has
+// the error recorded on its envelope.
+func (e *Engine) RetrieveCredentials(ctx context.Context, notifier *observer.Notifier, cpath, cpathexp *string,
+	teamIDs []identity.ID, skipDecryption, resolve bool) ([]PlaintextCredentialEnvelope, error) {
 	if cpath != nil && cpathexp != nil {
 		panic("cannot use both cpath and cpathexp")
 	}
@@ -296,14 +327,7 @@ func (e *Engine) RetrieveCredentials(ctx context.Context,
 					goto Decryption
 				}
 
-				cValue := apitypes.NewUndecryptedCredentialValue()
-				bv, err := json.Marshal(cValue)
-				if err != nil {
-					log.Printf("could not marshal undecrypted cvalue: %s", err)
-					return nil, err
-				}
-
-				cv, err := strconv.Unquote(string(bv))
+				cv, err := undecryptedValue()
 				if err != nil {
 					return nil, err
 				}
@@ -315,29 +339,60 @@ func (e *Engine) RetrieveCredentials(ctx context.Context,
 	}
 
 Decryption:
-	// Loop over the trees and unpack the credentials; later on we will
-	// actually do real work and decrypt each of these credentials but for
-	// now we just need ot return a list of them!
-	idx := newCredentialGraphKeyIndex(*(e.session.AuthID()))
-	idx.Add(activeGraphs...)
-
 	// All graphs will belong to the same org
 	orgID := activeGraphs[0].GetKeyring().OrgID()
 
+	kp, claimtree, err := e.fetchOrgKeys(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.unboxCredentials(ctx, activeGraphs, kp, claimtree, nil, func(cred envelope.CredentialInf, pt []byte) error {
+		// If this is a v1 credential, then we need to unmarshal the
+		// plain text value to check whether or not we should return
+		// the credentials.
+		if cred.GetVersion() == 1 {
+			cValue, err := extractCredentialValue(pt)
+			if err != nil {
+				log.Printf("could not unmarshal credential value from v1 cred: %s", err)
+				return err
+			}
+
+			if cValue.IsUnset() {
+				return nil
+			}
+		}
+
+		creds = append(creds, packagePlaintextCred(cred, string(pt)))
+		n.Notify(observer.Progress, "Credential decrypted", true)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resolve {
+		e.resolveReferences(ctx, creds)
+	}
+
+	return creds, nil
+}
+
+// fetchOrgKeys returns the session's keypairs for the given org, along with
+// the org's claimtree, which includes the public keys and their claims for all
+// users and machines inside the org.
+func (e *Engine) fetchOrgKeys(ctx context.Context, orgID *identity.ID) (*crypto.KeyPairs, *registry.ClaimTree, error) {
 	var fetchKeys sync.WaitGroup
 	var kps *registry.Keypairs
 	var claimtree *registry.ClaimTree
 	var kpsErr, ctErr error
 	fetchKeys.Add(2)
 
-	// Fetch the user's keypairs for this specific organization
 	go func() {
 		kps, kpsErr = e.client.KeyPairs.List(ctx, orgID)
 		fetchKeys.Done()
 	}()
 
-	// Fetch the org's claimtree which will include all public keys and their
-	// claims for all users and machines inside the org
 	go func() {
 		claimtree, ctErr = e.client.ClaimTree.Get(ctx, orgID, nil)
 		fetchKeys.Done()
@@ -346,34 +401,44 @@ Decryption:
 	fetchKeys.Wait()
 	if kpsErr != nil {
 		log.Printf("Cannot fetch keypairs for org[%s]: %s", orgID, kpsErr)
-		return nil, kpsErr
+		return nil, nil, kpsErr
 	}
 	if ctErr != nil {
 		log.Printf("Could not fetch claimtree for org[%s]: %s", orgID, ctErr)
-		return nil, ctErr
+		return nil, nil, ctErr
+	}
+
+	_, _, kp, err := fetchKeyPairs(kps, orgID)
+	if err != nil {
+		log.Printf("Error fetching keypairs: %s", err)
+		return nil, nil, err
 	}
 
-	// Cache the bundled crypto keypairs for reuse
-	keypairs := make(map[identity.ID]*crypto.KeyPairs)
+	return kp, claimtree, nil
+}
+
+// unboxCredentials decrypts the credentials in the given graphs, calling fn
+// with each credential and its plain text value. If include is not nil, only
+// the credentials it returns true for are decrypted.
+//
+// Graphs are grouped by the encryption key used to make the session a member
+// of their keyring, so each key only needs to be unsealed once.
+func (e *Engine) unboxCredentials(ctx context.Context, graphs []registry.CredentialGraph,
+	kp *crypto.KeyPairs, claimtree *registry.ClaimTree, include func(envelope.CredentialInf) bool,
+	fn func(envelope.CredentialInf, []byte) error) error {
+
+	idx := newCredentialGraphKeyIndex(*(e.session.AuthID()))
+	idx.Add(graphs...)
+
 	for encryptingKeyID, graphs := range idx.GetIndex() {
 		if len(graphs) == 0 {
 			continue
 		}
 
-		kp, ok := keypairs[*orgID]
-		if !ok {
-			_, _, kp, err = fetchKeyPairs(kps, orgID)
-			if err != nil {
-				log.Printf("Error fetching keypairs: %s", err)
-				return nil, err
-			}
-			keypairs[*orgID] = kp
-		}
-
 		encryptingKeySegment, err := claimtree.Find(&encryptingKeyID, false)
 		if err != nil {
 			log.Printf("Could not find encrypting key[%s]: %s", encryptingKeyID, err)
-			return nil, err
+			return err
 		}
 
 		encryptingKey := encryptingKeySegment.PublicKey.Body
@@ -387,29 +452,20 @@ Decryption:
 
 				err = unsealer.WithUnboxer(ctx, *mekshare.Key.Value, *mekshare.Key.Nonce, func(u crypto.Unboxer) error {
 					for _, cred := range graph.GetCredentials() {
+						if include != nil && !include(cred) {
+							continue
+						}
+
 						pt, err := u.Unbox(ctx, *cred.Credential().Value, *cred.Nonce(), *cred.Credential().Nonce)
 						if err != nil {
 							log.Printf("Error decrypting credential: %s", err)
 							return err
 						}
 
-						// If this is a v1 credential, then we need to unmarshal the
-						// plain text value to check whether or not we should return
-						// the credentials.
-						if cred.GetVersion() == 1 {
-							cValue, err := extractCredentialValue(pt)
-							if err != nil {
-								log.Printf("could not unmarshal credential value from v1 cred: %s", err)
-								return err
-							}
-
-							if cValue.IsUnset() {
-								continue
-							}
+						err = fn(cred, pt)
+						if err != nil {
+							return err
 						}
-
-						creds = append(creds, packagePlaintextCred(cred, string(pt)))
-						n.Notify(observer.Progress, "Credential decrypted", true)
 					}
 					return nil
 				})
@@ -423,11 +479,158 @@ Decryption:
 		})
 		if err != nil {
 			log.Printf("encountered an error while unsealing: %s", err)
-			return nil, err
+			return err
 		}
 	}
 
-	return creds, nil
+	return nil
+}
+
+// CredentialHistory returns every version of the credential with the given
+// name at the given PathExp, most recent first. Unset versions are included.
+// If skipDecryption is true, every version is returned with an undecrypted
+// value, though v1 versions are still decrypted to find their state.
+func (e *Engine) CredentialHistory(ctx context.Context, notifier *observer.Notifier,
+	pe *pathexp.PathExp, name string, skipDecryption bool) ([]CredentialHistoryEntry, error) {
+
+	n := notifier.Notifier(3)
+
+	graphs, err := e.client.CredentialGraph.List(ctx, "", pe, e.session.AuthID(), nil)
+	if err != nil {
+		log.Printf("error retrieving credential graph: %s", err)
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Credentials retrieved", true)
+
+	cgs := newCredentialGraphSet()
+	err = cgs.Add(graphs...)
+	if err != nil {
+		log.Printf("error creating credential graph set: %s", err)
+		return nil, err
+	}
+
+	versions, err := cgs.History(pe, name)
+	if err != nil {
+		log.Printf("error walking credential history: %s", err)
+		return nil, err
+	}
+
+	if len(versions) == 0 {
+		return nil, &apitypes.Error{
+			Type: apitypes.NotFoundError,
+			Err:  []string{"Credential not found"},
+		}
+	}
+
+	orgID := versions[0].Graph.GetKeyring().OrgID()
+	kp, claimtree, err := e.fetchOrgKeys(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Keys retrieved", true)
+
+	undecrypted, err := undecryptedValue()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the versions of this credential are decrypted. When decryption is
+	// skipped, v1 versions are still decrypted, as they store their unset
+	// state in the value itself, but their values aren't returned.
+	decrypt := func(cred envelope.CredentialInf) bool {
+		return !skipDecryption || cred.GetVersion() == 1
+	}
+
+	history := make([]CredentialHistoryEntry, len(versions))
+	offsets := make(map[identity.ID]int, len(versions))
+	seen := make(map[registry.CredentialGraph]bool)
+	var historyGraphs []registry.CredentialGraph
+	for i, v := range versions {
+		offsets[*v.Credential.GetID()] = i
+		if decrypt(v.Credential) && !seen[v.Graph] {
+			seen[v.Graph] = true
+			historyGraphs = append(historyGraphs, v.Graph)
+		}
+
+		history[i] = CredentialHistoryEntry{
+			Credential:        packagePlaintextCred(v.Credential, undecrypted),
+			CredentialVersion: v.Credential.CredentialVersion(),
+			KeyringVersion:    v.Graph.KeyringVersion(),
+			KeyringCreated:    keyringCreated(v.Graph),
+		}
+
+		if v.Credential.Unset() {
+			setUnsetState(&history[i].Credential)
+		}
+
+		if sigID := credentialSigner(v.Credential); sigID != nil {
+			segment, err := claimtree.Find(sigID, false)
+			if err != nil {
+				log.Printf("could not find signing key[%s]: %s", sigID, err)
+			} else {
+				history[i].SetBy = segment.PublicKey.Body.OwnerID
+			}
+		}
+	}
+
+	include := func(cred envelope.CredentialInf) bool {
+		_, ok := offsets[*cred.GetID()]
+		return ok && decrypt(cred)
+	}
+
+	err = e.unboxCredentials(ctx, historyGraphs, kp, claimtree, include, func(cred envelope.CredentialInf, pt []byte) error {
+		i := offsets[*cred.GetID()]
+
+		if !skipDecryption {
+			history[i].Credential.Body.Value = string(pt)
+		}
+
+		// v1 credentials store their unset state in the value itself.
+		if cred.GetVersion() == 1 {
+			cValue, err := extractCredentialValue(pt)
+			if err != nil {
+				log.Printf("could not unmarshal credential value from v1 cred: %s", err)
+				return err
+			}
+
+			if cValue.IsUnset() {
+				setUnsetState(&history[i].Credential)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Credentials decrypted", true)
+
+	return history, nil
+}
+
+// CurrentCredential returns the value of the most recent version of the
+// credential with the given name at the given PathExp. Only that version is
+// decrypted.
+func (e *Engine) CurrentCredential(ctx context.Context, pe *pathexp.PathExp,
+	name string) (*apitypes.CredentialValue, error) {
+
+	value, err := e.lookupReference(ctx, make(map[identity.ID]*orgKeys), pe, name)
+	if err != nil {
+		log.Printf("error retrieving current credential: %s", err)
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, &apitypes.Error{
+			Type: apitypes.NotFoundError,
+			Err:  []string{"Credential not found"},
+		}
+	}
+
+	return value, nil
 }
 
 // ApproveInvite approves an invitation of a user into an organzation by
@@ -0,0 +1,292 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/registry"
+
+	"github.com/manifoldco/torus-cli/daemon/crypto"
+)
+
+// References let a credential's value include the value of another
+// credential, e.g. postgres://${ref:/org/project/env/service/*/*/pghost}/db
+// A $ before a reference escapes it, so $${ref:...} is left as ${ref:...}.
+const (
+	refPrefix = "${ref:"
+	refSuffix = "}"
+)
+
+// referenceLookup returns the value of the credential with the given name at
+// the given PathExp, or nil if it does not exist, or has been unset.
+type referenceLookup func(pe *pathexp.PathExp, name string) (*apitypes.CredentialValue, error)
+
+// referenceResolver resolves references within credential values, including
+// references within the values of referenced credentials.
+type referenceResolver struct {
+	lookup referenceLookup
+
+	// values holds the resolved value of each reference seen so far.
+	values map[string]*apitypes.CredentialValue
+
+	// stack holds the credentials currently being resolved, to detect
+	// cycles.
+	stack []string
+}
+
+func newReferenceResolver(lookup referenceLookup) *referenceResolver {
+	return &referenceResolver{
+		lookup: lookup,
+		values: make(map[string]*apitypes.CredentialValue),
+	}
+}
+
+// Interpolate returns the given value of the credential identified by from,
+// with all references replaced by the values they refer to.
+//
+// If the value is a single reference, the referenced value is returned as is,
+// keeping its type. Otherwise, the result is a string.
+func (r *referenceResolver) Interpolate(from string, value *apitypes.CredentialValue) (*apitypes.CredentialValue, error) {
+	raw, err := value.Raw()
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := raw.(string)
+	if !ok || !strings.Contains(s, refPrefix) {
+		return value, nil
+	}
+
+	r.stack = append(r.stack, from)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+
+	out := &bytes.Buffer{}
+	for {
+		start := strings.Index(s, refPrefix)
+		if start == -1 {
+			out.WriteString(s)
+			break
+		}
+
+		if start > 0 && s[start-1] == '$' {
+			out.WriteString(s[:start-1] + refPrefix)
+			s = s[start+len(refPrefix):]
+			continue
+		}
+
+		end := strings.Index(s[start:], refSuffix)
+		if end == -1 {
+			return nil, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{fmt.Sprintf("Unterminated reference in secret %s", from)},
+			}
+		}
+		end += start
+
+		ref := s[start+len(refPrefix) : end]
+		resolved, err := r.resolve(from, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		// A value of only a single reference keeps the referenced type.
+		if out.Len() == 0 && start == 0 && end+len(refSuffix) == len(s) {
+			return resolved, nil
+		}
+
+		out.WriteString(s[:start])
+		out.WriteString(resolved.String())
+		s = s[end+len(refSuffix):]
+	}
+
+	return apitypes.NewStringCredentialValue(out.String()), nil
+}
+
+func (r *referenceResolver) resolve(from, ref string) (*apitypes.CredentialValue, error) {
+	pe, name, err := parseReference(ref)
+	if err != nil {
+		return nil, &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{fmt.Sprintf("Invalid reference %q in secret %s: %s", ref, from, err)},
+		}
+	}
+
+	key := referenceKey(pe, name)
+	if value, ok := r.values[key]; ok {
+		return value, nil
+	}
+
+	for i, s := range r.stack {
+		if s == key {
+			cycle := append(append([]string{}, r.stack[i:]...), key)
+			return nil, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"Reference cycle between secrets: " + strings.Join(cycle, " -> ")},
+			}
+		}
+	}
+
+	value, err := r.lookup(pe, name)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, &apitypes.Error{
+			Type: apitypes.NotFoundError,
+			Err: []string{fmt.Sprintf("Could not resolve reference %q in secret %s: "+
+				"the secret does not exist, or you do not have access to it", ref, from)},
+		}
+	}
+
+	value, err = r.Interpolate(key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	r.values[key] = value
+	return value, nil
+}
+
+// parseReference parses a reference into the PathExp and name of the
+// credential it refers to.
+func parseReference(ref string) (*pathexp.PathExp, string, error) {
+	idx := strings.LastIndex(ref, "/")
+	if idx == -1 {
+		return nil, "", fmt.Errorf("expected a full path, e.g. /org/project/env/service/*/*/name")
+	}
+
+	name := ref[idx+1:]
+	if name == "" || strings.Contains(name, "*") {
+		return nil, "", fmt.Errorf("invalid secret name %q", name)
+	}
+
+	pe, err := pathexp.Parse(ref[:idx])
+	if err != nil {
+		return nil, "", err
+	}
+
+	return pe, strings.ToLower(name), nil
+}
+
+func referenceKey(pe *pathexp.PathExp, name string) string {
+	return pe.String() + "/" + name
+}
+
+// Resolve replaces the references within the values of the given
+// credentials with the values they refer to.
+//
+// A credential whose references can't be resolved keeps its raw value, and
+// the error is recorded on its envelope, so one bad reference doesn't prevent
+// the other credentials from being retrieved.
+func (r *referenceResolver) Resolve(creds []PlaintextCredentialEnvelope) {
+	for i, cred := range creds {
+		if !strings.Contains(cred.Body.Value, refPrefix) {
+			continue
+		}
+
+		value, err := r.resolveCredential(cred.Body)
+		if err != nil {
+			log.Printf("error resolving references in credential %s: %s", cred.Body.Name, err)
+			creds[i].ReferenceError = err.Error()
+			continue
+		}
+
+		creds[i].Body.Value = value
+	}
+}
+
+// resolveCredential returns the plaintext value of the credential, with its
+// references resolved.
+func (r *referenceResolver) resolveCredential(cred *PlaintextCredential) (string, error) {
+	value, err := extractCredentialValue([]byte(cred.Value))
+	if err != nil {
+		return "", err
+	}
+
+	value, err = r.Interpolate(referenceKey(cred.PathExp, cred.Name), value)
+	if err != nil {
+		return "", err
+	}
+
+	return credentialValuePlaintext(value)
+}
+
+// resolveReferences resolves the references within the values of the given
+// credentials. Referenced credentials are looked up and decrypted as the
+// current session, so only those it has access to can be referenced.
+func (e *Engine) resolveReferences(ctx context.Context, creds []PlaintextCredentialEnvelope) {
+	keys := make(map[identity.ID]*orgKeys)
+	r := newReferenceResolver(func(pe *pathexp.PathExp, name string) (*apitypes.CredentialValue, error) {
+		return e.lookupReference(ctx, keys, pe, name)
+	})
+
+	r.Resolve(creds)
+}
+
+// orgKeys holds the keys needed to decrypt credentials within an org.
+type orgKeys struct {
+	kp        *crypto.KeyPairs
+	claimtree *registry.ClaimTree
+}
+
+// lookupReference returns the decrypted value of the most recent version of
+// the credential with the given name and PathExp, fetching the keys for its org
+// if they are not already in keys.
+func (e *Engine) lookupReference(ctx context.Context, keys map[identity.ID]*orgKeys,
+	pe *pathexp.PathExp, name string) (*apitypes.CredentialValue, error) {
+
+	graphs, err := e.client.CredentialGraph.List(ctx, "", pe, e.session.AuthID(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cgs := newCredentialGraphSet()
+	err = cgs.Add(graphs...)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := cgs.History(pe, name)
+	if err != nil || len(versions) == 0 || versions[0].Credential.Unset() {
+		return nil, err
+	}
+	head := versions[0]
+
+	orgID := head.Graph.GetKeyring().OrgID()
+	k, ok := keys[*orgID]
+	if !ok {
+		k = &orgKeys{}
+		k.kp, k.claimtree, err = e.fetchOrgKeys(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		keys[*orgID] = k
+	}
+
+	var value *apitypes.CredentialValue
+	isHead := func(cred envelope.CredentialInf) bool {
+		return *cred.GetID() == *head.Credential.GetID()
+	}
+	err = e.unboxCredentials(ctx, []registry.CredentialGraph{head.Graph}, k.kp, k.claimtree, isHead,
+		func(cred envelope.CredentialInf, pt []byte) error {
+			value, err = extractCredentialValue(pt)
+			return err
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	// v1 credentials store their unset state in the value itself.
+	if value != nil && value.IsUnset() {
+		return nil, nil
+	}
+
+	return value, nil
+}
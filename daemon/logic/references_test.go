@@ -0,0 +1,129 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func fakeLookup(values map[string]*apitypes.CredentialValue) referenceLookup {
+	return func(pe *pathexp.PathExp, name string) (*apitypes.CredentialValue, error) {
+		return values[referenceKey(pe, name)], nil
+	}
+}
+
+func TestReferenceResolverInterpolate(t *testing.T) {
+	values := map[string]*apitypes.CredentialValue{
+		"/o/p/e/s/*/*/host":  apitypes.NewStringCredentialValue("db.internal"),
+		"/o/p/e/s/*/*/port":  apitypes.NewIntCredentialValue(5432),
+		"/o/p/e/s/*/*/addr":  apitypes.NewStringCredentialValue("${ref:/o/p/e/s/*/*/host}:${ref:/o/p/e/s/*/*/port}"),
+		"/o/p/e/s/*/*/a":     apitypes.NewStringCredentialValue("${ref:/o/p/e/s/*/*/b}"),
+		"/o/p/e/s/*/*/b":     apitypes.NewStringCredentialValue("x${ref:/o/p/e/s/*/*/a}"),
+		"/o/p/e/s/*/*/alias": apitypes.NewStringCredentialValue("${ref:/o/p/e/s/*/*/port}"),
+	}
+
+	tcs := []struct {
+		name  string
+		value string
+		want  string
+		raw   interface{}
+		err   string
+	}{
+		{name: "no references", value: "plain", want: "plain"},
+		{name: "interpolated", value: "postgres://${ref:/o/p/e/s/*/*/host}/db", want: "postgres://db.internal/db"},
+		{name: "keeps type", value: "${ref:/o/p/e/s/*/*/port}", want: "5432", raw: 5432},
+		{name: "keeps type through references", value: "${ref:/o/p/e/s/*/*/alias}", want: "5432", raw: 5432},
+		{name: "nested", value: "tcp://${ref:/o/p/e/s/*/*/addr}", want: "tcp://db.internal:5432"},
+		{name: "escaped", value: "$${ref:/o/p/e/s/*/*/host}", want: "${ref:/o/p/e/s/*/*/host}"},
+		{name: "missing", value: "${ref:/o/p/e/s/*/*/nope}", err: "Could not resolve reference"},
+		{name: "unterminated", value: "${ref:/o/p/e/s/*/*/host", err: "Unterminated reference"},
+		{name: "invalid", value: "${ref:host}", err: "Invalid reference"},
+		{name: "cycle", value: "${ref:/o/p/e/s/*/*/a}", err: "Reference cycle"},
+		{name: "self", value: "${ref:/o/p/e/s/*/*/self}", err: "Reference cycle"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newReferenceResolver(fakeLookup(values))
+			values["/o/p/e/s/*/*/self"] = apitypes.NewStringCredentialValue(tc.value)
+
+			got, err := r.Interpolate("/o/p/e/s/*/*/self", apitypes.NewStringCredentialValue(tc.value))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("Interpolate(%q) expected error %q, got %v", tc.value, tc.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Interpolate(%q) expected no errors, got %s", tc.value, err)
+			}
+
+			if got.String() != tc.want {
+				t.Errorf("Interpolate(%q) expected %q, got %q", tc.value, tc.want, got.String())
+			}
+
+			if tc.raw != nil {
+				if raw, _ := got.Raw(); raw != tc.raw {
+					t.Errorf("Interpolate(%q) expected raw value %#v, got %#v", tc.value, tc.raw, raw)
+				}
+			}
+		})
+	}
+}
+
+func TestReferenceResolverResolve(t *testing.T) {
+	values := map[string]*apitypes.CredentialValue{
+		"/o/p/e/s/*/*/host": apitypes.NewStringCredentialValue("db.internal"),
+	}
+
+	pe, err := pathexp.Parse("/o/p/e/s/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cred := func(name, value string) PlaintextCredentialEnvelope {
+		pt, err := credentialValuePlaintext(apitypes.NewStringCredentialValue(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return PlaintextCredentialEnvelope{
+			Version: 2,
+			Body:    &PlaintextCredential{Name: name, PathExp: pe, Value: pt},
+		}
+	}
+
+	value := func(cred PlaintextCredentialEnvelope) string {
+		v, err := extractCredentialValue([]byte(cred.Body.Value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v.String()
+	}
+
+	creds := []PlaintextCredentialEnvelope{
+		cred("url", "postgres://${ref:/o/p/e/s/*/*/host}/db"),
+		cred("broken", "${ref:/o/p/e/s/*/*/nope}"),
+		cred("plain", "plain"),
+	}
+
+	newReferenceResolver(fakeLookup(values)).Resolve(creds)
+
+	if v := value(creds[0]); v != "postgres://db.internal/db" || creds[0].ReferenceError != "" {
+		t.Errorf("Expected url to be resolved, got %q with error %q", v, creds[0].ReferenceError)
+	}
+
+	if v := value(creds[1]); v != "${ref:/o/p/e/s/*/*/nope}" {
+		t.Errorf("Expected broken to keep its raw value, got %q", v)
+	}
+	if !strings.Contains(creds[1].ReferenceError, "Could not resolve reference") {
+		t.Errorf("Expected broken to have a reference error, got %q", creds[1].ReferenceError)
+	}
+
+	if v := value(creds[2]); v != "plain" || creds[2].ReferenceError != "" {
+		t.Errorf("Expected plain to be unchanged, got %q with error %q", v, creds[2].ReferenceError)
+	}
+}
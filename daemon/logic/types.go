@@ -1,8 +1,11 @@
 package logic
 
 import (
+	"time"
+
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
 )
 
 // PlaintextCredentialEnvelope is an unencrypted credential object
@@ -10,6 +13,10 @@ type PlaintextCredentialEnvelope struct {
 	ID      *identity.ID         `json:"id"`
 	Version uint8                `json:"version"`
 	Body    *PlaintextCredential `json:"body"`
+
+	// ReferenceError is set if the references within the credential's value
+	// could not be resolved, in which case the value is left as it is.
+	ReferenceError string `json:"reference_error,omitempty"`
 }
 
 // PlaintextCredential is the body of an unencrypted Credential
@@ -20,4 +27,20 @@ type PlaintextCredential struct {
 	ProjectID *identity.ID     `json:"project_id"`
 	Value     string           `json:"value"`
 	State     *string          `json:"state"`
+
+	Metadata *primitive.CredentialMetadata `json:"metadata,omitempty"`
+
+	// ClearMetadata lists the metadata fields to clear when setting the
+	// credential; see mergeCredentialMetadata.
+	ClearMetadata []string `json:"clear_metadata,omitempty"`
+}
+
+// CredentialHistoryEntry is a single version of a Credential, along with the
+// version of the keyring it was stored in, and who set it.
+type CredentialHistoryEntry struct {
+	Credential        PlaintextCredentialEnvelope `json:"credential"`
+	CredentialVersion int                         `json:"credential_version"`
+	KeyringVersion    int                         `json:"keyring_version"`
+	KeyringCreated    time.Time                   `json:"keyring_created_at"`
+	SetBy             *identity.ID                `json:"set_by"`
 }
@@ -70,10 +70,115 @@ func packagePlaintextCred(c envelope.CredentialInf, value string) PlaintextCrede
 			OrgID:     c.OrgID(),
 			Value:     value,
 			State:     &state,
+			Metadata:  c.Metadata(),
 		},
 	}
 }
 
+// mergeCredentialMetadata returns the metadata for a new version of a
+// credential: the previous version's metadata, with the fields given in update
+// replacing its own, and the fields named in clear removed. Nil is returned if
+// no fields remain.
+func mergeCredentialMetadata(previous, update *primitive.CredentialMetadata,
+	clear []string) *primitive.CredentialMetadata {
+
+	m := primitive.CredentialMetadata{}
+	if previous != nil {
+		m = *previous
+	}
+
+	if update != nil {
+		if update.Description != "" {
+			m.Description = update.Description
+		}
+		if update.ExpiresAt != nil {
+			m.ExpiresAt = update.ExpiresAt
+		}
+		if update.OwnerID != nil {
+			m.OwnerID = update.OwnerID
+		}
+		if len(update.Tags) > 0 {
+			m.Tags = update.Tags
+		}
+	}
+
+	for _, field := range clear {
+		switch field {
+		case apitypes.MetadataDescription:
+			m.Description = ""
+		case apitypes.MetadataExpires:
+			m.ExpiresAt = nil
+		case apitypes.MetadataOwner:
+			m.OwnerID = nil
+		case apitypes.MetadataTags:
+			m.Tags = nil
+		}
+	}
+
+	if m.Description == "" && m.ExpiresAt == nil && m.OwnerID == nil && len(m.Tags) == 0 {
+		return nil
+	}
+
+	return &m
+}
+
+// setUnsetState marks the credential as unset, clearing its value.
+func setUnsetState(c *PlaintextCredentialEnvelope) {
+	state := "unset"
+	c.Body.State = &state
+	c.Body.Value = ""
+}
+
+// undecryptedValue returns the plain text form of an undecrypted credential
+// value, for use in place of a credential's value when it is not decrypted.
+func undecryptedValue() (string, error) {
+	pt, err := credentialValuePlaintext(apitypes.NewUndecryptedCredentialValue())
+	if err != nil {
+		log.Printf("could not marshal undecrypted cvalue: %s", err)
+	}
+
+	return pt, err
+}
+
+// credentialValuePlaintext returns the plain text form of a credential value,
+// as stored in a PlaintextCredential. It is the inverse of
+// extractCredentialValue.
+func credentialValuePlaintext(v *apitypes.CredentialValue) (string, error) {
+	bv, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Unquote(string(bv))
+}
+
+// credentialSigner returns the ID of the public key that signed the given
+// credential.
+func credentialSigner(c envelope.CredentialInf) *identity.ID {
+	switch cred := c.(type) {
+	case *envelope.Credential:
+		return cred.Signature.PublicKeyID
+	case *envelope.CredentialV2:
+		return cred.Signature.PublicKeyID
+	case *envelope.CredentialV1:
+		return cred.Signature.PublicKeyID
+	default:
+		return nil
+	}
+}
+
+// keyringCreated returns the time the keyring of the given graph was created.
+func keyringCreated(g registry.CredentialGraph) time.Time {
+	switch graph := g.(type) {
+	case *registry.CredentialGraphV1:
+		return graph.Keyring.Body.Created
+	case *registry.CredentialGraphV2:
+		return graph.Keyring.Body.Created
+	default:
+		return time.Time{}
+	}
+}
+
 func extractCredentialValue(pt []byte) (*apitypes.CredentialValue, error) {
 	cValue := &apitypes.CredentialValue{}
 	err := json.Unmarshal([]byte(strconv.Quote(string(pt))), cValue)
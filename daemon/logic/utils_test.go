@@ -0,0 +1,71 @@
+package logic
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestMergeCredentialMetadata(t *testing.T) {
+	owner := identity.ID{0x01, 0x02}
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	previous := &primitive.CredentialMetadata{
+		Description: "Primary DB",
+		ExpiresAt:   &expires,
+		OwnerID:     &owner,
+		Tags:        []string{"db"},
+	}
+
+	tcs := []struct {
+		name     string
+		previous *primitive.CredentialMetadata
+		update   *primitive.CredentialMetadata
+		clear    []string
+		want     *primitive.CredentialMetadata
+	}{
+		{name: "nothing", want: nil},
+		{name: "kept", previous: previous, want: previous},
+		{
+			name:   "new",
+			update: &primitive.CredentialMetadata{Description: "Replica"},
+			want:   &primitive.CredentialMetadata{Description: "Replica"},
+		},
+		{
+			name:     "merged",
+			previous: previous,
+			update:   &primitive.CredentialMetadata{Tags: []string{"prod"}},
+			want: &primitive.CredentialMetadata{
+				Description: "Primary DB", ExpiresAt: &expires, OwnerID: &owner, Tags: []string{"prod"},
+			},
+		},
+		{
+			name:     "cleared",
+			previous: previous,
+			update:   &primitive.CredentialMetadata{Description: "Replica"},
+			clear:    []string{"owner", "expires"},
+			want:     &primitive.CredentialMetadata{Description: "Replica", Tags: []string{"db"}},
+		},
+		{
+			name:     "all cleared",
+			previous: previous,
+			clear:    []string{"description", "owner", "tags", "expires"},
+			want:     nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := mergeCredentialMetadata(tc.previous, tc.update, tc.clear)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("mergeCredentialMetadata() expected %#v, got %#v", tc.want, got)
+			}
+		})
+	}
+
+	if previous.Description != "Primary DB" || previous.OwnerID == nil {
+		t.Error("mergeCredentialMetadata() modified the previous metadata")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"sort"
+	"time"
 
 	"github.com/manifoldco/go-base64"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/prefs"
 	"github.com/manifoldco/torus-cli/primitive"
 	"github.com/manifoldco/torus-cli/registry"
 
@@ -42,6 +44,7 @@ func newWorklog(e *Engine) Worklog {
 			apitypes.SecretRotateWorklogType:    &secretRotateHandler{engine: e},
 			apitypes.MissingKeypairsWorklogType: &missingKeypairsHandler{engine: e},
 			apitypes.InviteApproveWorklogType:   &inviteApproveHandler{engine: e},
+			apitypes.SecretStaleWorklogType:     &secretStaleHandler{engine: e},
 			membersType:                         &keyringMembersHandler{engine: e},
 		},
 	}
@@ -129,7 +132,8 @@ type secretRotateHandler struct {
 }
 
 func (secretRotateHandler) resolveErr() string {
-	// This won't happen, because rotation must be manual. Let's include an
+	// This won't happen, because secrets are rotated by the CLI, using the
+	// rotation plugins configured for them, or manually. Let's include an
 	// error message just in case, though!
 	return "Error rotating secret"
 }
@@ -203,6 +207,131 @@ func (h *secretRotateHandler) resolve(ctx context.Context, n *observer.Notifier,
 	return errManualResolve
 }
 
+type secretStaleHandler struct {
+	engine *Engine
+}
+
+func (secretStaleHandler) resolveErr() string {
+	// Like rotation, this is resolved by the CLI, or manually.
+	return "Error rotating secret"
+}
+
+func (h *secretStaleHandler) list(ctx context.Context, org *envelope.Org) ([]apitypes.WorklogItem, error) {
+	// The max ages are loaded each time, so changes to them apply without
+	// restarting the daemon.
+	maxAges, err := prefs.LoadSecretMaxAges()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only look at the org if any max ages could apply to it.
+	relevant := false
+	for _, m := range maxAges {
+		if m.PathExp.Org.Contains(org.Body.Name) {
+			relevant = true
+			break
+		}
+	}
+	if !relevant {
+		return nil, nil
+	}
+
+	projects, err := h.engine.client.Projects.List(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	cgs := newCredentialGraphSet()
+	for _, project := range projects {
+		graphs, err := h.engine.client.CredentialGraph.Search(ctx,
+			"/"+org.Body.Name+"/"+project.Body.Name+"/*/*/*/*",
+			h.engine.session.AuthID(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		err = cgs.Add(graphs...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	creds, err := cgs.ActiveCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	return staleCredentials(creds, maxAges, time.Now()), nil
+}
+
+func (h *secretStaleHandler) resolve(ctx context.Context, n *observer.Notifier,
+	orgID *identity.ID, item *apitypes.WorklogItem) error {
+	return errManualResolve
+}
+
+// staleCredentials returns a worklog item for each of the given credentials
+// that is older than the max age of its path expression, as of now.
+//
+// Credentials from before the time they were set was recorded are treated as
+// being set when their keyring was created.
+func staleCredentials(creds []credentialVersion, maxAges []prefs.SecretMaxAge, now time.Time) []apitypes.WorklogItem {
+	var items []apitypes.WorklogItem
+	for _, c := range creds {
+		cred := c.Credential
+		maxAge := matchMaxAge(maxAges, cred.PathExp())
+		if maxAge == nil {
+			continue
+		}
+
+		setAt := cred.Created()
+		if setAt.IsZero() {
+			setAt = keyringCreated(c.Graph)
+		}
+
+		if now.Sub(setAt) < maxAge.MaxAge {
+			continue
+		}
+
+		item := apitypes.WorklogItem{
+			Details: &apitypes.SecretStaleWorklogDetails{
+				PathExp:       cred.PathExp(),
+				Name:          cred.Name(),
+				SetAt:         setAt,
+				MaxAge:        maxAge.MaxAge,
+				MaxAgePathExp: maxAge.PathExp,
+			},
+		}
+		item.CreateID(apitypes.SecretStaleWorklogType)
+
+		items = append(items, item)
+	}
+
+	// Always return the items in a consistent order.
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Subject() < items[j].Subject()
+	})
+
+	return items
+}
+
+// matchMaxAge returns the max age with the most specific path expression
+// containing pe, preferring the first defined when they are equally specific.
+// It returns nil if no max age applies to pe.
+func matchMaxAge(maxAges []prefs.SecretMaxAge, pe *pathexp.PathExp) *prefs.SecretMaxAge {
+	var match *prefs.SecretMaxAge
+	for i, m := range maxAges {
+		if !m.PathExp.Contains(pe) {
+			continue
+		}
+
+		if match == nil || m.PathExp.CompareSpecificity(match.PathExp) > 0 {
+			match = &maxAges[i]
+		}
+	}
+
+	return match
+}
+
 type missingKeypairsHandler struct {
 	engine *Engine
 }
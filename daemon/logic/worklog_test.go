@@ -0,0 +1,104 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/prefs"
+	"github.com/manifoldco/torus-cli/primitive"
+	"github.com/manifoldco/torus-cli/registry"
+)
+
+func TestMatchMaxAge(t *testing.T) {
+	maxAges := []prefs.SecretMaxAge{
+		{PathExp: mustPathExp("/o/p/*/*/*/*"), MaxAge: 3 * time.Hour},
+		{PathExp: mustPathExp("/o/p/production/*/*/*"), MaxAge: time.Hour},
+		{PathExp: mustPathExp("/o/q/production/*/*/*"), MaxAge: 2 * time.Hour},
+		{PathExp: mustPathExp("/o/p/[production|staging]/*/*/*"), MaxAge: 4 * time.Hour},
+	}
+
+	tcs := []struct {
+		pe   string
+		want time.Duration
+	}{
+		{"/o/p/production/s/*/*", time.Hour},
+		{"/o/p/staging/s/*/*", 4 * time.Hour},
+		{"/o/p/dev/s/*/*", 3 * time.Hour},
+		{"/o/p/*/s/*/*", 3 * time.Hour},
+		{"/o/q/production/s/*/*", 2 * time.Hour},
+		{"/o/q/staging/s/*/*", 0},
+		{"/other/p/production/s/*/*", 0},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.pe, func(t *testing.T) {
+			m := matchMaxAge(maxAges, mustPathExp(tc.pe))
+			if tc.want == 0 {
+				if m != nil {
+					t.Errorf("matchMaxAge() expected no match, got %s", m.PathExp)
+				}
+				return
+			}
+
+			if m == nil || m.MaxAge != tc.want {
+				t.Errorf("matchMaxAge() expected max age %s, got %v", tc.want, m)
+			}
+		})
+	}
+}
+
+func TestStaleCredentials(t *testing.T) {
+	now := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	graph := buildGraph("/o/p/e/s/*/*", 1)
+	graph.(*registry.CredentialGraphV2).Keyring.Body.Created = now.Add(-100 * day)
+
+	version := func(name string, created time.Time) credentialVersion {
+		body := &primitive.Credential{
+			BaseCredential: primitive.BaseCredential{
+				Name:    name,
+				PathExp: mustPathExp("/o/p/e/s/*/*"),
+			},
+			Created: created,
+		}
+		return credentialVersion{
+			Credential: &envelope.Credential{ID: id1, Version: 3, Body: body},
+			Graph:      graph,
+		}
+	}
+
+	creds := []credentialVersion{
+		version("old", now.Add(-91*day)),
+		version("new", now.Add(-day)),
+		version("untracked", time.Time{}),
+	}
+	maxAges := []prefs.SecretMaxAge{
+		{PathExp: mustPathExp("/o/p/*/*/*/*"), MaxAge: 90 * day},
+	}
+
+	items := staleCredentials(creds, maxAges, now)
+	if len(items) != 2 {
+		t.Fatalf("staleCredentials() expected 2 items, got %d", len(items))
+	}
+
+	for i, name := range []string{"old", "untracked"} {
+		d := items[i].Details.(*apitypes.SecretStaleWorklogDetails)
+		if d.Name != name {
+			t.Errorf("staleCredentials() expected item %d to be %s, got %s", i, name, d.Name)
+		}
+		if items[i].Type() != apitypes.SecretStaleWorklogType {
+			t.Errorf("staleCredentials() expected item %d to be a stale secret item, got %s", i, items[i].Type())
+		}
+	}
+
+	if d := items[1].Details.(*apitypes.SecretStaleWorklogDetails); !d.SetAt.Equal(now.Add(-100 * day)) {
+		t.Errorf("staleCredentials() expected untracked secret to use keyring creation time, got %s", d.SetAt)
+	}
+
+	if items := staleCredentials(creds, nil, now); len(items) != 0 {
+		t.Errorf("staleCredentials() expected no items without max ages, got %d", len(items))
+	}
+}
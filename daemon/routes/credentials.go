@@ -8,9 +8,11 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/daemon/logic"
 	"github.com/manifoldco/torus-cli/daemon/observer"
 	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
 )
 
 func credentialsGetRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
@@ -29,6 +31,7 @@ func credentialsGetRoute(engine *logic.Engine, o *observer.Observer) http.Handle
 		path := q.Get("path")
 		pathexp := q.Get("pathexp")
 		skip := q.Get("skip-decryption") == "true"
+		resolve := q.Get("resolve-references") == "true"
 		if path == "" && pathexp == "" {
 			err = errors.New("missing path or pathexp")
 			log.Printf("Error constructing request: %s", err)
@@ -52,9 +55,9 @@ func credentialsGetRoute(engine *logic.Engine, o *observer.Observer) http.Handle
 
 		var creds []logic.PlaintextCredentialEnvelope
 		if path != "" {
-			creds, err = engine.RetrieveCredentials(ctx, n, &path, nil, teamIDs, skip)
+			creds, err = engine.RetrieveCredentials(ctx, n, &path, nil, teamIDs, skip, resolve)
 		} else {
-			creds, err = engine.RetrieveCredentials(ctx, n, nil, &pathexp, teamIDs, skip)
+			creds, err = engine.RetrieveCredentials(ctx, n, nil, &pathexp, teamIDs, skip, resolve)
 		}
 		if err != nil {
 			// Rely on logs inside engine for debugging
@@ -112,3 +115,91 @@ func credentialsPostRoute(engine *logic.Engine, o *observer.Observer) http.Handl
 		}
 	}
 }
+
+func credentialsHistoryRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		q := r.URL.Query()
+		n, err := o.Notifier(ctx, 1)
+		if err != nil {
+			log.Printf("Error creating parent Notifier: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		name := q.Get("name")
+		skip := q.Get("skip-decryption") == "true"
+		if name == "" {
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"missing name"},
+			})
+			return
+		}
+
+		pe, err := pathexp.Parse(q.Get("pathexp"))
+		if err != nil {
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"missing or invalid pathexp"},
+			})
+			return
+		}
+
+		history, err := engine.CredentialHistory(ctx, n, pe, name, skip)
+		if err != nil {
+			// Rely on logs inside engine for debugging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n.Notify(observer.Finished, "Completed Operation", true)
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(history)
+		if err != nil {
+			log.Printf("error encoding credential history: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+	}
+}
+
+func credentialsCurrentRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		name := q.Get("name")
+		if name == "" {
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"missing name"},
+			})
+			return
+		}
+
+		pe, err := pathexp.Parse(q.Get("pathexp"))
+		if err != nil {
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"missing or invalid pathexp"},
+			})
+			return
+		}
+
+		value, err := engine.CurrentCredential(r.Context(), pe, name)
+		if err != nil {
+			// Rely on logs inside engine for debugging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(value)
+		if err != nil {
+			log.Printf("error encoding current credential: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+	}
+}
@@ -40,6 +40,8 @@ func NewRouteMux(c *config.Config, s session.Session, db *db.DB,
 	mux.PostFunc("/keypairs/revoke", keypairsRevokeRoute(lEngine, o))
 
 	mux.GetFunc("/credentials", credentialsGetRoute(lEngine, o))
+	mux.GetFunc("/credentials/history", credentialsHistoryRoute(lEngine, o))
+	mux.GetFunc("/credentials/current", credentialsCurrentRoute(lEngine))
 	mux.PostFunc("/credentials", credentialsPostRoute(lEngine, o))
 
 	mux.PostFunc("/org-invites/:id/approve",
@@ -2,16 +2,31 @@
 package dirprefs
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
 // DirPreferences holds preferences for arguments set in .torus.json files
 type DirPreferences struct {
 	Organization string `json:"org,omitempty"`
 	Project      string `json:"project,omitempty"`
-	Path         string `json:"-"`
+	Environment  string `json:"environment,omitempty"`
+	Service      string `json:"service,omitempty"`
+
+	// Branches maps git branch names, or patterns (e.g. feature/*), to the
+	// environment to use when that branch is checked out.
+	Branches map[string]string `json:"branches,omitempty"`
+
+	// Aliases maps secret names to the names they are exposed as by run and
+	// export.
+	Aliases map[string]string `json:"aliases,omitempty"`
+
+	Path string `json:"-"`
 }
 
 // Load loads DirPreferences. It starts in the current working directory,
@@ -56,6 +71,57 @@ func Load(recurse bool) (*DirPreferences, error) {
 	return prefs, nil
 }
 
+// BranchEnvironment returns the environment mapped to the given branch. An
+// exact match is preferred, followed by the longest matching pattern. It
+// returns an empty string if no environment is mapped to the branch.
+func (d *DirPreferences) BranchEnvironment(branch string) string {
+	if branch == "" {
+		return ""
+	}
+
+	if env, ok := d.Branches[branch]; ok {
+		return env
+	}
+
+	best := ""
+	for pattern := range d.Branches {
+		if ok, _ := path.Match(pattern, branch); !ok {
+			continue
+		}
+
+		// Break ties between equally long patterns by name, so the result
+		// doesn't depend on map ordering.
+		if best == "" || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best = pattern
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+
+	return d.Branches[best]
+}
+
+// CurrentBranch returns the git branch checked out in the directory holding
+// the .torus.json file. It returns an empty string if the directory isn't in
+// a git repository, HEAD is detached, or git isn't installed.
+func (d *DirPreferences) CurrentBranch() string {
+	if d.Path == "" {
+		return ""
+	}
+
+	out := &bytes.Buffer{}
+	cmd := exec.Command("git", "symbolic-ref", "--quiet", "--short", "HEAD")
+	cmd.Dir = filepath.Dir(d.Path)
+	cmd.Stdout = out
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(out.String())
+}
+
 // Save writes the DirPreferences values to the file in the struct's Path
 // field
 func (d *DirPreferences) Save() error {
@@ -0,0 +1,34 @@
+package dirprefs
+
+import "testing"
+
+func TestBranchEnvironment(t *testing.T) {
+	d := &DirPreferences{
+		Branches: map[string]string{
+			"main":           "production",
+			"release/*":      "staging",
+			"release/v1.*":   "legacy",
+			"feature/*":      "dev",
+			"feature/[a-z]*": "dev-alpha",
+		},
+	}
+
+	tcs := []struct {
+		branch string
+		want   string
+	}{
+		{"main", "production"},
+		{"release/v2.0", "staging"},
+		{"release/v1.4", "legacy"},
+		{"feature/login", "dev-alpha"},
+		{"feature/1234", "dev"},
+		{"other", ""},
+		{"", ""},
+	}
+
+	for _, tc := range tcs {
+		if got := d.BranchEnvironment(tc.branch); got != tc.want {
+			t.Errorf("BranchEnvironment(%q) expected %q, got %q", tc.branch, tc.want, got)
+		}
+	}
+}
@@ -4,6 +4,7 @@ package envelope
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/manifoldco/go-base64"
 
@@ -214,6 +215,9 @@ type CredentialInf interface {
 
 	OrgID() *identity.ID
 	ProjectID() *identity.ID
+
+	Metadata() *primitive.CredentialMetadata
+	Created() time.Time
 }
 
 // GetVersion returns the schema version of this Credential.
@@ -269,6 +273,18 @@ func (c *CredentialV1) ProjectID() *identity.ID {
 	return c.Body.ProjectID
 }
 
+// Metadata returns the metadata describing this Credential. Version 1
+// credentials do not have metadata, so it is always nil.
+func (CredentialV1) Metadata() *primitive.CredentialMetadata {
+	return nil
+}
+
+// Created returns when this Credential was created. Version 1 credentials do
+// not track this, so it is always the zero time.
+func (CredentialV1) Created() time.Time {
+	return time.Time{}
+}
+
 // GetVersion returns the schema version of this Credential.
 func (c *Credential) GetVersion() uint8 {
 	return c.Version
@@ -320,3 +336,78 @@ func (c *Credential) OrgID() *identity.ID {
 func (c *Credential) ProjectID() *identity.ID {
 	return c.Body.ProjectID
 }
+
+// Metadata returns the metadata describing this Credential, or nil if it has
+// none.
+func (c *Credential) Metadata() *primitive.CredentialMetadata {
+	return c.Body.Metadata
+}
+
+// Created returns when this Credential was created.
+func (c *Credential) Created() time.Time {
+	return c.Body.Created
+}
+
+// GetVersion returns the schema version of this Credential.
+func (c *CredentialV2) GetVersion() uint8 {
+	return c.Version
+}
+
+// Previous returns the ID of the previous versino of this Credential, or nil
+// if this Credential has no previous version.
+func (c *CredentialV2) Previous() *identity.ID {
+	return c.Body.Previous
+}
+
+// CredentialVersion returns the monotomically incremented version of the
+// Credential for this PathExp/Name pair.
+func (c *CredentialV2) CredentialVersion() int {
+	return c.Body.CredentialVersion
+}
+
+// PathExp returns the path expression for this Credential's location.
+func (c *CredentialV2) PathExp() *pathexp.PathExp {
+	return c.Body.PathExp
+}
+
+// Name returns this Credential's name.
+func (c *CredentialV2) Name() string {
+	return c.Body.Name
+}
+
+// Unset returns a bool indicating if this Credential has been explicitly unset.
+func (c *CredentialV2) Unset() bool {
+	return c.Body.State != nil && *c.Body.State == "unset"
+}
+
+// Nonce returns the Nonce for this Credential's encrypted value.
+func (c *CredentialV2) Nonce() *base64.Value {
+	return c.Body.Nonce
+}
+
+// Credential returns the encrypted CredentialValue for this Credential.
+func (c *CredentialV2) Credential() *primitive.CredentialValue {
+	return c.Body.Credential
+}
+
+// OrgID returns the ID of the Org that this Credential belongs to.
+func (c *CredentialV2) OrgID() *identity.ID {
+	return c.Body.OrgID
+}
+
+// ProjectID returns the ID of the Project that this Credential belongs to.
+func (c *CredentialV2) ProjectID() *identity.ID {
+	return c.Body.ProjectID
+}
+
+// Metadata returns the metadata describing this Credential. Version 2
+// credentials do not have metadata, so it is always nil.
+func (CredentialV2) Metadata() *primitive.CredentialMetadata {
+	return nil
+}
+
+// Created returns when this Credential was created. Version 2 credentials do
+// not track this, so it is always the zero time.
+func (CredentialV2) Created() time.Time {
+	return time.Time{}
+}
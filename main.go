@@ -29,6 +29,7 @@ func main() {
 	app.HelpName = "torus"
 	app.Usage = "A secure, shared workspace for secrets"
 	app.Version = config.Version
+	app.Flags = cmd.GlobalFlags
 	app.Commands = cmd.Cmds
 	app.Run(os.Args)
 }
@@ -0,0 +1,70 @@
+package prefs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+// maxAgeSection is the torusrc section holding the maximum age of secrets,
+// keyed by the path expression they apply to, e.g.
+//
+//	[max_age]
+//	/myorg/api/production/*/*/* = 90d
+const maxAgeSection = "max_age"
+
+// SecretMaxAge is the maximum age of the secrets within a path expression,
+// after which they should be rotated.
+type SecretMaxAge struct {
+	PathExp *pathexp.PathExp
+	MaxAge  time.Duration
+}
+
+// LoadSecretMaxAges returns the maximum secret ages configured in the
+// torusrc file, in the order they are defined.
+func LoadSecretMaxAges() ([]SecretMaxAge, error) {
+	keys, err := loadSection(maxAgeSection)
+	if err != nil {
+		return nil, err
+	}
+
+	var ages []SecretMaxAge
+	for _, k := range keys {
+		pe, err := pathexp.Parse(k.Name())
+		if err != nil {
+			return nil, fmt.Errorf("invalid path expression %q in [%s]: %s", k.Name(), maxAgeSection, err)
+		}
+
+		maxAge, err := ParseMaxAge(k.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid max age for %s in [%s]: %s", k.Name(), maxAgeSection, err)
+		}
+
+		ages = append(ages, SecretMaxAge{PathExp: pe, MaxAge: maxAge})
+	}
+
+	return ages, nil
+}
+
+// ParseMaxAge parses a maximum age, given as a number of days (e.g. 90d), or
+// a duration (e.g. 36h).
+func ParseMaxAge(raw string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if strings.HasSuffix(raw, "d") {
+		var days int
+		days, err = strconv.Atoi(strings.TrimSuffix(raw, "d"))
+		d = time.Duration(days) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(raw)
+	}
+
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%q is not a positive number of days (90d) or duration (36h)", raw)
+	}
+
+	return d, nil
+}
@@ -144,3 +144,32 @@ func NewPreferences() (*Preferences, error) {
 	err = ini.MapTo(prefs, filePath)
 	return prefs, err
 }
+
+// loadSection returns the keys of the given section of the torusrc file, in
+// the order they are defined. Missing files or sections have no keys.
+func loadSection(name string) ([]*ini.Key, error) {
+	filePath, err := RcPath()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := ini.Load(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	section, err := f.GetSection(name)
+	if err != nil { // the section doesn't exist
+		return nil, nil
+	}
+
+	return section.Keys(), nil
+}
@@ -0,0 +1,157 @@
+package prefs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+// rotationSection is the torusrc section holding how secrets are rotated,
+// keyed by the path of the secrets they apply to, e.g.
+//
+//	[rotation]
+//	/myorg/api/production/*/*/*/db_password = exec /usr/local/bin/rotate-db
+//	/myorg/api/production/*/*/*/session_key = generate hex 32
+//	/myorg/api/production/*/*/*/admin_password = generate password 24 alphabet=symbols
+const rotationSection = "rotation"
+
+// The defaults of generate rotations, matching those of the generate command.
+const (
+	defaultRotationLength   = 32
+	defaultRotationAlphabet = "alnum"
+	defaultRotationBits     = 4096
+)
+
+// SecretRotation describes how to rotate the secrets with the given name
+// within a path expression. A Name of * applies to all secrets.
+type SecretRotation struct {
+	PathExp *pathexp.PathExp
+	Name    string
+
+	// Plugin is either "exec" or "generate", and Args are the command and
+	// its arguments to execute, or the kind of value to generate followed by
+	// its options.
+	Plugin string
+	Args   []string
+
+	// Length, Alphabet and Bits are the options of a generate rotation,
+	// given after the kind as [length] [alphabet=ALPHABET] [bits=BITS].
+	Length   int
+	Alphabet string
+	Bits     int
+}
+
+// String returns the configured rotation, as written in the torusrc file.
+func (r *SecretRotation) String() string {
+	return strings.Join(append([]string{r.Plugin}, r.Args...), " ")
+}
+
+// LoadSecretRotations returns the secret rotations configured in the torusrc
+// file, in the order they are defined.
+func LoadSecretRotations() ([]SecretRotation, error) {
+	keys, err := loadSection(rotationSection)
+	if err != nil {
+		return nil, err
+	}
+
+	var rotations []SecretRotation
+	for _, k := range keys {
+		r, err := parseSecretRotation(k.Name(), k.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid rotation for %s in [%s]: %s", k.Name(), rotationSection, err)
+		}
+
+		rotations = append(rotations, *r)
+	}
+
+	return rotations, nil
+}
+
+func parseSecretRotation(path, value string) (*SecretRotation, error) {
+	idx := strings.LastIndex(path, "/")
+	if idx == -1 {
+		return nil, fmt.Errorf("expected a path, e.g. /org/project/env/service/*/*/name")
+	}
+
+	pe, err := pathexp.Parse(path[:idx])
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.ToLower(path[idx+1:])
+	if name == "" || (name != "*" && !pathexp.ValidSecret(name)) {
+		return nil, fmt.Errorf("invalid secret name %q", name)
+	}
+
+	parts := strings.Fields(value)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("missing plugin, expected exec or generate")
+	}
+
+	r := &SecretRotation{
+		PathExp: pe,
+		Name:    name,
+		Plugin:  parts[0],
+		Args:    parts[1:],
+	}
+
+	switch parts[0] {
+	case "exec":
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("missing command to execute")
+		}
+	case "generate":
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("expected generate <kind> [length] [alphabet=ALPHABET] [bits=BITS]")
+		}
+		if err := parseGenerateOptions(r, parts[2:]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown plugin %q, expected exec or generate", parts[0])
+	}
+
+	return r, nil
+}
+
+// parseGenerateOptions sets the length, alphabet and bits of a generate
+// rotation from its options, using the defaults for those not given.
+func parseGenerateOptions(r *SecretRotation, options []string) error {
+	r.Length = defaultRotationLength
+	r.Alphabet = defaultRotationAlphabet
+	r.Bits = defaultRotationBits
+
+	seen := make(map[string]bool)
+	for _, o := range options {
+		key, value := "length", o
+		if idx := strings.Index(o, "="); idx != -1 {
+			key, value = o[:idx], o[idx+1:]
+		}
+		if seen[key] {
+			return fmt.Errorf("%s given more than once", key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "length":
+			r.Length, err = strconv.Atoi(value)
+		case "alphabet":
+			if value == "" {
+				err = fmt.Errorf("empty alphabet")
+			}
+			r.Alphabet = value
+		case "bits":
+			r.Bits, err = strconv.Atoi(value)
+		default:
+			return fmt.Errorf("unknown option %q, expected length, alphabet or bits", key)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", key, value)
+		}
+	}
+
+	return nil
+}
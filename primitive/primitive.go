@@ -41,6 +41,14 @@ func (v2Schema) Version() int {
 	return 2
 }
 
+// v3Schema embeds in other structs to indicate their schema version is 3.
+type v3Schema struct{}
+
+// Version returns the schema version of structs that embed this type.
+func (v3Schema) Version() int {
+	return 3
+}
+
 // LoginPublicKey represents the public component of a asymmetric key used to
 // authenticate against the registry
 type LoginPublicKey struct {
@@ -206,6 +214,17 @@ func NewClaim(orgID, ownerID, previous, pubKeyID *identity.ID, claimType ClaimTy
 // Credential is a secret value shared between a group of services based
 // on users identity, operating environment, project, and organization
 type Credential struct { // type: 0x0b
+	v3Schema
+	immutable
+	BaseCredential
+	Created  time.Time           `json:"created_at"`
+	Metadata *CredentialMetadata `json:"metadata"`
+	State    *string             `json:"state"`
+}
+
+// CredentialV2 is a secret value shared between a group of services based
+// on users identity, operating environment, project, and organization
+type CredentialV2 struct { // type: 0x0b
 	v2Schema
 	immutable
 	BaseCredential
@@ -234,6 +253,15 @@ type BaseCredential struct {
 	CredentialVersion int              `json:"version"`
 }
 
+// CredentialMetadata describes a Credential. Unlike the Credential's value, it
+// is not encrypted, but it is signed along with the rest of the Credential.
+type CredentialMetadata struct {
+	Description string       `json:"description"`
+	ExpiresAt   *time.Time   `json:"expires_at"`
+	OwnerID     *identity.ID `json:"owner_id"`
+	Tags        []string     `json:"tags"`
+}
+
 // CredentialValue is the secretbox encrypted value of the containing
 // Credential.
 type CredentialValue struct {
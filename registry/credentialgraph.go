@@ -68,6 +68,13 @@ func (r *rawGraph) convert() (CredentialGraph, error) {
 				Signature: ec.Signature,
 				Body:      ec.Body.(*primitive.CredentialV1),
 			}
+		case *primitive.CredentialV2:
+			creds[i] = &envelope.CredentialV2{
+				ID:        ec.ID,
+				Version:   ec.Version,
+				Signature: ec.Signature,
+				Body:      ec.Body.(*primitive.CredentialV2),
+			}
 		case *primitive.Credential:
 			creds[i] = &envelope.Credential{
 				ID:        ec.ID,